@@ -0,0 +1,356 @@
+// api 把图书馆的书籍增删改查提供为HTTP JSON接口，接口的说明见 openapi.json(GET /openapi.json)
+package api
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"library-management/library"
+	"library-management/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+// 分页的默认值和上限
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Book 是接口中书籍的JSON格式
+type Book struct {
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Author   string  `json:"author"`
+	ISBN     string  `json:"isbn"`
+	ISBN10   string  `json:"isbn10,omitempty"` // 只读，由ISBN计算
+	Category string  `json:"category"`
+	Price    float64 `json:"price"`
+}
+
+// BookList 是列表接口返回的一页书籍
+type BookList struct {
+	Books    []Book `json:"books"`
+	Total    int    `json:"total"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Pages    int    `json:"pages"`
+}
+
+// 错误的JSON格式
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// NewBook 把models.Book转换为接口中的格式
+func NewBook(b models.Book) Book {
+	isbn10, _ := models.ISBN13To10(b.ISBN)
+	return Book{
+		ID:       b.ID,
+		Title:    b.Title,
+		Author:   b.Author,
+		ISBN:     b.ISBN,
+		ISBN10:   isbn10,
+		Category: b.Category.String(),
+		Price:    b.Price,
+	}
+}
+
+// ETag 根据书的内容计算，内容不变ETag就不变
+func ETag(b models.Book) string {
+	data, _ := json.Marshal(b)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// Server 处理HTTP请求
+type Server struct {
+	lib *library.Library
+	mux *http.ServeMux
+}
+
+// NewServer 创建一个使用lib的Server
+func NewServer(lib *library.Library) *Server {
+	s := &Server{lib: lib, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /openapi.json", s.openAPI)
+	s.mux.HandleFunc("GET /books", s.listBooks)
+	s.mux.HandleFunc("POST /books", s.createBook)
+	s.mux.HandleFunc("GET /books/{id}", s.getBook)
+	s.mux.HandleFunc("PUT /books/{id}", s.updateBook)
+	s.mux.HandleFunc("DELETE /books/{id}", s.deleteBook)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, errorBody{Error: fmt.Sprintf(format, args...)})
+}
+
+// 把图书馆返回的错误转换成状态码
+func writeLibraryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, library.ErrBookNotFound):
+		writeError(w, http.StatusNotFound, "%v", err)
+	case errors.Is(err, library.ErrBookChanged):
+		writeError(w, http.StatusPreconditionFailed, "%v", err)
+	case errors.Is(err, library.ErrDuplicateISBN), errors.Is(err, library.ErrBookOnLoan):
+		writeError(w, http.StatusConflict, "%v", err)
+	case errors.Is(err, models.ErrInvalidISBN):
+		writeError(w, http.StatusUnprocessableEntity, "%v", err)
+	default:
+		writeError(w, http.StatusInternalServerError, "%v", err)
+	}
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPI)
+}
+
+// 从路径中取出书的ID
+func bookID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid book id %q", r.PathValue("id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// GET /books?q=&tokens=&category=&min_price=&max_price=&sort=&desc=&page=&page_size=
+func (s *Server) listBooks(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := library.Query{
+		Text:     params.Get("q"),
+		Page:     1,
+		PageSize: DefaultPageSize,
+	}
+
+	var err error
+	intParam := func(name string, into *int, min, max int) {
+		if v := params.Get(name); v != "" && err == nil {
+			n, perr := strconv.Atoi(v)
+			if perr != nil || n < min || n > max {
+				err = fmt.Errorf("%s must be an integer between %d and %d", name, min, max)
+				return
+			}
+			*into = n
+		}
+	}
+	floatParam := func(name string, into *float64) {
+		if v := params.Get(name); v != "" && err == nil {
+			f, perr := strconv.ParseFloat(v, 64)
+			if perr != nil || f < 0 {
+				err = fmt.Errorf("%s must be a non-negative number", name)
+				return
+			}
+			*into = f
+		}
+	}
+	boolParam := func(name string, into *bool) {
+		if v := params.Get(name); v != "" && err == nil {
+			b, perr := strconv.ParseBool(v)
+			if perr != nil {
+				err = fmt.Errorf("%s must be true or false", name)
+				return
+			}
+			*into = b
+		}
+	}
+
+	intParam("page", &query.Page, 1, 1<<30)
+	intParam("page_size", &query.PageSize, 1, MaxPageSize)
+	floatParam("min_price", &query.MinPrice)
+	floatParam("max_price", &query.MaxPrice)
+	boolParam("tokens", &query.Tokens)
+	boolParam("desc", &query.Desc)
+	if v := params.Get("category"); v != "" && err == nil {
+		category, cerr := models.ParseCategory(v)
+		if cerr != nil {
+			err = cerr
+		}
+		query.Category = &category
+	}
+	if v := params.Get("sort"); v != "" && err == nil {
+		query.SortBy, err = library.ParseSortField(v)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	result := s.lib.Search(query)
+	list := BookList{
+		Books:    make([]Book, 0, len(result.Books)),
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: query.PageSize,
+		Pages:    result.Pages,
+	}
+	for _, b := range result.Books {
+		list.Books = append(list.Books, NewBook(b))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// 读取并检查请求中的书籍
+func readBook(w http.ResponseWriter, r *http.Request) (models.Book, bool) {
+	var in Book
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %v", err)
+		return models.Book{}, false
+	}
+
+	var problems []string
+	if strings.TrimSpace(in.Title) == "" {
+		problems = append(problems, "title is required")
+	}
+	if in.Price < 0 {
+		problems = append(problems, "price must not be negative")
+	}
+	category, err := models.ParseCategory(in.Category)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("category must be one of %s", strings.Join(models.CategoryNames, ", ")))
+	}
+	if in.ISBN != "" {
+		if _, err := models.NormalizeISBN(in.ISBN); err != nil {
+			problems = append(problems, "isbn is not a valid ISBN-10 or ISBN-13")
+		}
+	}
+	if len(problems) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, "%s", strings.Join(problems, "; "))
+		return models.Book{}, false
+	}
+
+	return models.Book{
+		Title:    strings.TrimSpace(in.Title),
+		Author:   strings.TrimSpace(in.Author),
+		ISBN:     in.ISBN,
+		Category: category,
+		Price:    in.Price,
+	}, true
+}
+
+// POST /books
+func (s *Server) createBook(w http.ResponseWriter, r *http.Request) {
+	in, ok := readBook(w, r)
+	if !ok {
+		return
+	}
+	id, err := s.lib.AddBook(in.Title, in.Author, in.ISBN, in.Category, in.Price)
+	if err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	book, _ := s.lib.GetBook(id)
+	w.Header().Set("Location", fmt.Sprintf("/books/%d", id))
+	w.Header().Set("ETag", ETag(book))
+	writeJSON(w, http.StatusCreated, NewBook(book))
+}
+
+// GET /books/{id}，If-None-Match和ETag相同时返回304
+func (s *Server) getBook(w http.ResponseWriter, r *http.Request) {
+	id, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	book, exists := s.lib.GetBook(id)
+	if !exists {
+		writeError(w, http.StatusNotFound, "book %d not found", id)
+		return
+	}
+	etag := ETag(book)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewBook(book))
+}
+
+// 修改和删除必须带If-Match，防止覆盖别人的修改
+func ifMatch(w http.ResponseWriter, r *http.Request) (func(models.Book) bool, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		writeError(w, http.StatusPreconditionRequired, "If-Match header with the book's ETag is required")
+		return nil, false
+	}
+	return func(current models.Book) bool {
+		return etagMatches(header, ETag(current), false)
+	}, true
+}
+
+// PUT /books/{id}
+func (s *Server) updateBook(w http.ResponseWriter, r *http.Request) {
+	id, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	match, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	in, ok := readBook(w, r)
+	if !ok {
+		return
+	}
+	if err := s.lib.UpdateBookIf(id, match, in.Title, in.Author, in.ISBN, in.Category, in.Price); err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	book, _ := s.lib.GetBook(id)
+	w.Header().Set("ETag", ETag(book))
+	writeJSON(w, http.StatusOK, NewBook(book))
+}
+
+// DELETE /books/{id}
+func (s *Server) deleteBook(w http.ResponseWriter, r *http.Request) {
+	id, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	match, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := s.lib.DeleteBookIf(id, match); err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 判断If-Match或者If-None-Match头是否包含etag，*匹配任何ETag
+// If-None-Match使用弱比较，忽略W/前缀；If-Match使用强比较(RFC 9110)，W/开头的ETag不匹配
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	"library-management/library"
+	"library-management/models"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 发送一个请求，headers是成对的名称和值
+func do(s *Server, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+// 有三本书的服务器：1 Dune(ISBN 9780306406157)，2 Go，3 Cosmos
+func testServer(t *testing.T) (*Server, *library.Library) {
+	t.Helper()
+
+	lib := library.NewLibrary()
+	lib.AddBook("Dune", "Herbert", "0306406152", models.Fiction, 10)
+	lib.AddBook("Go", "Donovan", "", models.Computer, 40)
+	lib.AddBook("Cosmos", "Sagan", "", models.Science, 20)
+	return NewServer(lib), lib
+}
+
+func TestStatusCodes(t *testing.T) {
+	s, lib := testServer(t)
+	etag := ETag(models.Book{ID: 2, Title: "Go", Author: "Donovan", Category: models.Computer, Price: 40})
+
+	// 第3本书借出之后不能删除
+	lib.AddCopies(3, 1)
+	member, _ := lib.AddMember(models.Member{Name: "Ann"})
+	lib.Checkout(member, 3)
+
+	for _, tc := range []struct {
+		name, method, path, body string
+		headers                  []string
+		want                     int
+	}{
+		{"openapi", "GET", "/openapi.json", "", nil, http.StatusOK},
+		{"list", "GET", "/books", "", nil, http.StatusOK},
+		{"get", "GET", "/books/1", "", nil, http.StatusOK},
+		{"get missing", "GET", "/books/99", "", nil, http.StatusNotFound},
+		{"bad id", "GET", "/books/abc", "", nil, http.StatusBadRequest},
+		{"zero id", "GET", "/books/0", "", nil, http.StatusBadRequest},
+		{"method not allowed", "PATCH", "/books/1", "", nil, http.StatusMethodNotAllowed},
+		{"create", "POST", "/books", `{"title":"New","category":"Science","price":5}`, nil, http.StatusCreated},
+		{"create bad json", "POST", "/books", `{"title":`, nil, http.StatusBadRequest},
+		{"create unknown field", "POST", "/books", `{"title":"X","category":"Science","pages":3}`, nil, http.StatusBadRequest},
+		{"create invalid", "POST", "/books", `{"title":" ","category":"Poetry","price":-1}`, nil, http.StatusUnprocessableEntity},
+		{"create bad isbn", "POST", "/books", `{"title":"X","category":"Science","isbn":"123"}`, nil, http.StatusUnprocessableEntity},
+		{"create duplicate isbn", "POST", "/books", `{"title":"X","category":"Science","isbn":"0306406152"}`, nil, http.StatusConflict},
+		{"update without If-Match", "PUT", "/books/2", `{"title":"Go","category":"Computer"}`, nil, http.StatusPreconditionRequired},
+		{"update missing", "PUT", "/books/99", `{"title":"Go","category":"Computer"}`, []string{"If-Match", "*"}, http.StatusNotFound},
+		{"update duplicate isbn", "PUT", "/books/2", `{"title":"Go","category":"Computer","isbn":"9780306406157"}`, []string{"If-Match", etag}, http.StatusConflict},
+		{"delete on loan", "DELETE", "/books/3", "", []string{"If-Match", "*"}, http.StatusConflict},
+		{"delete without If-Match", "DELETE", "/books/2", "", nil, http.StatusPreconditionRequired},
+		{"list bad page", "GET", "/books?page=0", "", nil, http.StatusBadRequest},
+		{"list page size too big", "GET", "/books?page_size=101", "", nil, http.StatusBadRequest},
+		{"list bad price", "GET", "/books?min_price=-1", "", nil, http.StatusBadRequest},
+		{"list bad bool", "GET", "/books?desc=maybe", "", nil, http.StatusBadRequest},
+		{"list bad category", "GET", "/books?category=poetry", "", nil, http.StatusBadRequest},
+		{"list bad sort", "GET", "/books?sort=isbn", "", nil, http.StatusBadRequest},
+	} {
+		w := do(s, tc.method, tc.path, tc.body, tc.headers...)
+		if w.Code != tc.want {
+			t.Errorf("%s: %s %s = %d %s, want %d", tc.name, tc.method, tc.path, w.Code, w.Body, tc.want)
+		}
+		if w.Code >= 400 && w.Code != http.StatusMethodNotAllowed {
+			var body errorBody
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == "" {
+				t.Errorf("%s: error body %q", tc.name, w.Body)
+			}
+		}
+	}
+}
+
+func TestCreateAndGet(t *testing.T) {
+	s, _ := testServer(t)
+
+	w := do(s, "POST", "/books", `{"title":" Cosmos 2 ","author":"Sagan","isbn":"0-13-419044-0","category":"science","price":25}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/books/4" || w.Header().Get("ETag") == "" {
+		t.Fatalf("create = %d %v", w.Code, w.Header())
+	}
+	etag := w.Header().Get("ETag")
+	var created Book
+	json.Unmarshal(w.Body.Bytes(), &created)
+	want := Book{ID: 4, Title: "Cosmos 2", Author: "Sagan", ISBN: "9780134190440", ISBN10: "0134190440", Category: "Science", Price: 25}
+	if created != want {
+		t.Errorf("created %+v, want %+v", created, want)
+	}
+
+	w = do(s, "GET", "/books/4", "")
+	var got Book
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if got != want || w.Header().Get("ETag") != etag {
+		t.Errorf("get = %+v with ETag %q, want %+v with ETag %q", got, w.Header().Get("ETag"), want, etag)
+	}
+}
+
+// ETag用于条件请求：If-None-Match返回304，If-Match不匹配时返回412
+func TestETag(t *testing.T) {
+	s, _ := testServer(t)
+
+	etag := do(s, "GET", "/books/2", "").Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if w := do(s, "GET", "/books/2", "", "If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match with the current ETag = %d", w.Code)
+	}
+	if w := do(s, "GET", "/books/2", "", "If-None-Match", "W/"+etag); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match with the weak current ETag = %d", w.Code)
+	}
+	if w := do(s, "GET", "/books/2", "", "If-None-Match", `"other"`); w.Code != http.StatusOK {
+		t.Errorf("If-None-Match with another ETag = %d", w.Code)
+	}
+	if w := do(s, "GET", "/books/1", ""); w.Header().Get("ETag") == etag {
+		t.Error("different books have the same ETag")
+	}
+
+	update := `{"title":"Go 2","author":"Donovan","category":"Computer","price":45}`
+	if w := do(s, "PUT", "/books/2", update, "If-Match", `"stale"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("update with a stale ETag = %d", w.Code)
+	}
+	// If-Match使用强比较，弱ETag不匹配
+	if w := do(s, "PUT", "/books/2", update, "If-Match", "W/"+etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("update with the weak current ETag = %d", w.Code)
+	}
+	w := do(s, "PUT", "/books/2", update, "If-Match", `"stale", `+etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update with the current ETag = %d %s", w.Code, w.Body)
+	}
+	newETag := w.Header().Get("ETag")
+	if newETag == "" || newETag == etag || newETag != do(s, "GET", "/books/2", "").Header().Get("ETag") {
+		t.Errorf("ETag after update %q, before %q", newETag, etag)
+	}
+
+	// 别人修改之后旧的ETag不能再用
+	if w := do(s, "DELETE", "/books/2", "", "If-Match", etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("delete with the old ETag = %d", w.Code)
+	}
+	if w := do(s, "DELETE", "/books/2", "", "If-Match", newETag); w.Code != http.StatusNoContent {
+		t.Errorf("delete with the current ETag = %d", w.Code)
+	}
+	if w := do(s, "GET", "/books/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("get after delete = %d", w.Code)
+	}
+}
+
+func TestListBooks(t *testing.T) {
+	s, _ := testServer(t)
+
+	for _, tc := range []struct {
+		query string
+		ids   []int
+		total int
+		pages int
+	}{
+		{"", []int{1, 2, 3}, 3, 1},
+		{"?sort=price&desc=true", []int{2, 3, 1}, 3, 1},
+		{"?sort=title&page_size=2", []int{3, 1}, 3, 2},
+		{"?sort=title&page_size=2&page=2", []int{2}, 3, 2},
+		{"?page=5", []int{}, 3, 1},
+		{"?q=dune", []int{1}, 1, 1},
+		{"?q=978-0-306-40615-7", []int{1}, 1, 1},
+		{"?category=computer", []int{2}, 1, 1},
+		{"?min_price=15&max_price=30", []int{3}, 1, 1},
+	} {
+		w := do(s, "GET", "/books"+tc.query, "")
+		var list BookList
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", tc.query, w.Code, w.Body)
+		}
+		ids := make([]int, 0)
+		for _, b := range list.Books {
+			ids = append(ids, b.ID)
+		}
+		if !reflect.DeepEqual(ids, tc.ids) || list.Total != tc.total || list.Pages != tc.pages {
+			t.Errorf("%s: got %v total %d pages %d, want %v total %d pages %d",
+				tc.query, ids, list.Total, list.Pages, tc.ids, tc.total, tc.pages)
+		}
+	}
+}
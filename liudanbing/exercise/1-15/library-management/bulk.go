@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"library-management/fileio"
+	"library-management/library"
+	"strings"
+)
+
+func bulkMenu(lib *library.Library, scanner *bufio.Scanner) {
+	fmt.Println("\n-------- import / export --------")
+	fmt.Println("1. Import books from CSV")
+	fmt.Println("2. Import books from MARC")
+	fmt.Println("3. Export books to CSV")
+	fmt.Println("4. Export books to MARC")
+	fmt.Print("Your choice(1-4): ")
+	scanner.Scan()
+
+	switch scanner.Text() {
+	case "1":
+		importBooks(lib, scanner, fileio.CSV)
+	case "2":
+		importBooks(lib, scanner, fileio.MARC)
+	case "3":
+		exportBooks(lib, scanner, fileio.CSV)
+	case "4":
+		exportBooks(lib, scanner, fileio.MARC)
+	default:
+		fmt.Println("Invalid choice!")
+	}
+}
+
+// 读取CSV的列映射
+func scanMapping(scanner *bufio.Scanner) (fileio.ColumnMapping, bool) {
+	fmt.Printf("Columns are named %s by default.\n", strings.Join(fileio.Fields, ", "))
+	fmt.Print("Enter the column mapping, e.g. title=Book Name,price=Cost (leave blank for default): ")
+	scanner.Scan()
+	mapping, err := fileio.ParseMapping(scanner.Text())
+	if err != nil {
+		fmt.Println("Error:", err)
+		return nil, false
+	}
+	return mapping, true
+}
+
+// 先检查文件(dry run)并打印报告，确认之后再导入
+func importBooks(lib *library.Library, scanner *bufio.Scanner, format fileio.Format) {
+	fmt.Printf("Enter the name of the file you want to import (e.g. books.%s): ", format)
+	scanner.Scan()
+	filename := scanner.Text()
+
+	opts := fileio.ImportOptions{Format: format, DryRun: true}
+	if format == fileio.CSV {
+		mapping, ok := scanMapping(scanner)
+		if !ok {
+			return
+		}
+		opts.Mapping = mapping
+	}
+
+	report, err := fileio.ImportBooks(lib, filename, opts)
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		return
+	}
+	report.Print()
+	if !report.Valid() {
+		fmt.Println("Please fix the errors above and import again!")
+		return
+	}
+	if len(report.Rows) == 0 {
+		return
+	}
+
+	fmt.Printf("Import %d books? (y/N): ", len(report.Rows))
+	scanner.Scan()
+	if !strings.EqualFold(scanner.Text(), "y") {
+		fmt.Println("Import cancelled!")
+		return
+	}
+	opts.DryRun = false
+	report, err = fileio.ImportBooks(lib, filename, opts)
+	if err != nil {
+		fmt.Println("Error importing books:", err)
+		return
+	}
+	report.Print()
+}
+
+func exportBooks(lib *library.Library, scanner *bufio.Scanner, format fileio.Format) {
+	fmt.Printf("Enter the name of the file you want to export (e.g. books.%s): ", format)
+	scanner.Scan()
+	filename := scanner.Text()
+
+	opts := fileio.ExportOptions{Format: format}
+	if format == fileio.CSV {
+		mapping, ok := scanMapping(scanner)
+		if !ok {
+			return
+		}
+		opts.Mapping = mapping
+	}
+
+	if err := fileio.ExportBooks(lib, filename, opts); err != nil {
+		fmt.Println("Error exporting books:", err)
+		return
+	}
+	fmt.Println("Books exported successfully!")
+}
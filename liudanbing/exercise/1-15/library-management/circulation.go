@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"library-management/library"
+	"library-management/models"
+	"strconv"
+	"time"
+)
+
+func circulationMenu(lib *library.Library, scanner *bufio.Scanner) {
+	fmt.Println("\n-------- circulation --------")
+	fmt.Println("1. Add copies of a book")
+	fmt.Println("2. Check out a book")
+	fmt.Println("3. Return a copy")
+	fmt.Println("4. Renew a loan")
+	fmt.Println("5. List loans of a member")
+	fmt.Println("6. List overdue loans")
+	fmt.Println("7. Pay a fine")
+	fmt.Println("8. Place a hold")
+	fmt.Println("9. Cancel a hold")
+	fmt.Println("10. Show the hold queue of a book")
+	fmt.Print("Your choice(1-10): ")
+	scanner.Scan()
+
+	switch scanner.Text() {
+	case "1":
+		bookID, ok := scanInt(scanner, "Enter the book ID: ")
+		if !ok {
+			return
+		}
+		n, ok := scanInt(scanner, "Enter the number of copies: ")
+		if !ok || n <= 0 {
+			fmt.Println("Invalid number!")
+			return
+		}
+		ids, err := lib.AddCopies(bookID, n)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Copies added with IDs: %v\n", ids)
+	case "2":
+		memberID, ok := scanInt(scanner, "Enter the member ID: ")
+		if !ok {
+			return
+		}
+		bookID, ok := scanInt(scanner, "Enter the book ID: ")
+		if !ok {
+			return
+		}
+		loan, err := lib.Checkout(memberID, bookID)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Copy %d checked out, due %s\n", loan.CopyID, loan.Due.Format("2006-01-02"))
+	case "3":
+		copyID, ok := scanInt(scanner, "Enter the copy ID: ")
+		if !ok {
+			return
+		}
+		loan, err := lib.Return(copyID)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if loan.Fine > 0 {
+			fmt.Printf("Copy returned %d days late, fine: %.2f\n", loan.DaysOverdue(loan.Returned), loan.Fine)
+		} else {
+			fmt.Println("Copy returned on time!")
+		}
+		if cp, _ := lib.GetCopy(copyID); cp.Status == models.OnHold {
+			fmt.Printf("Please put the copy aside for hold %d!\n", cp.HoldID)
+		}
+	case "4":
+		loanID, ok := scanInt(scanner, "Enter the loan ID: ")
+		if !ok {
+			return
+		}
+		loan, err := lib.Renew(loanID)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Loan renewed, due %s\n", loan.Due.Format("2006-01-02"))
+	case "5":
+		memberID, ok := scanInt(scanner, "Enter the member ID: ")
+		if !ok {
+			return
+		}
+		loans := lib.MemberLoans(memberID)
+		if len(loans) == 0 {
+			fmt.Println("No loans!")
+		}
+		for _, loan := range loans {
+			loan.PrintDetails()
+		}
+	case "6":
+		now := time.Now()
+		loans := lib.OverdueLoans(now)
+		if len(loans) == 0 {
+			fmt.Println("No overdue loans!")
+		}
+		for _, loan := range loans {
+			fmt.Printf("%d days overdue: ", loan.DaysOverdue(now))
+			loan.PrintDetails()
+		}
+	case "7":
+		memberID, ok := scanInt(scanner, "Enter the member ID: ")
+		if !ok {
+			return
+		}
+		fmt.Print("Enter the amount: ")
+		scanner.Scan()
+		amount, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil || amount <= 0 {
+			fmt.Println("Invalid amount!")
+			return
+		}
+		left, err := lib.PayFine(memberID, amount)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Fine paid, %.2f left\n", left)
+	case "8":
+		memberID, ok := scanInt(scanner, "Enter the member ID: ")
+		if !ok {
+			return
+		}
+		bookID, ok := scanInt(scanner, "Enter the book ID: ")
+		if !ok {
+			return
+		}
+		hold, err := lib.PlaceHold(memberID, bookID)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Hold placed with ID: %d, position in queue: %d\n", hold.ID, len(lib.BookHolds(bookID)))
+	case "9":
+		holdID, ok := scanInt(scanner, "Enter the hold ID: ")
+		if !ok {
+			return
+		}
+		if err := lib.CancelHold(holdID); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Println("Hold cancelled!")
+	case "10":
+		bookID, ok := scanInt(scanner, "Enter the book ID: ")
+		if !ok {
+			return
+		}
+		holds := lib.BookHolds(bookID)
+		if len(holds) == 0 {
+			fmt.Println("No holds!")
+		}
+		for _, hold := range holds {
+			hold.PrintDetails()
+		}
+	default:
+		fmt.Println("Invalid choice!")
+	}
+}
+
+// 读取一个整数，输入非法时打印提示并返回false
+func scanInt(scanner *bufio.Scanner, prompt string) (int, bool) {
+	fmt.Print(prompt)
+	scanner.Scan()
+	n, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		fmt.Println("Invalid number!")
+		return 0, false
+	}
+	return n, true
+}
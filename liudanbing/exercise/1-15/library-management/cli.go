@@ -0,0 +1,541 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"library-management/api"
+	"library-management/fileio"
+	"library-management/library"
+	"library-management/models"
+	"library-management/store"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// 非交互命令的说明，没有命令时进入菜单
+const commandUsage = `Usage:
+  library-management [-store kind] [-db path]                      interactive menu
+  library-management [-store kind] [-db path] books <command> ...  run one command
+
+Commands:
+  books add     -title T [-author A] [-isbn I] [-category C] [-price P]
+  books list    [-sort field] [-desc]
+  books get     <id> | -isbn I
+  books update  <id> [-title T] [-author A] [-isbn I] [-category C] [-price P]
+  books delete  <id>
+  books search  [-q text | text...] [-tokens] [-category C] [-min-price P] [-max-price P]
+                [-sort field] [-desc] [-page N] [-page-size N]
+  books import  [-format csv|marc] [-map mapping] [-dry-run] <file>
+  books export  [-format csv|marc] [-map mapping] <file>
+
+Every command also accepts:
+  -data file      JSON data file to read and save (as written by the menu), instead of -store
+  -output format  table or json (default table)
+
+Run "library-management books <command> -h" for the flags of a command.
+`
+
+// 命令的输出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// 参数错误，打印用法并以2退出
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+// books的子命令
+var bookCommands = map[string]func(c *cli, args []string) error{
+	"add":    booksAdd,
+	"list":   booksList,
+	"get":    booksGet,
+	"update": booksUpdate,
+	"delete": booksDelete,
+	"search": booksSearch,
+	"import": booksImport,
+	"export": booksExport,
+}
+
+// cli 是一个命令运行时的状态
+type cli struct {
+	storeKind, dbPath string // 全局的-store和-db，没有指定-store时storeKind为空
+	data              string // -data
+	output            string // -output
+	lib               *library.Library
+}
+
+// 运行args中的命令，返回进程的退出码；storeKind为空时使用-data或者默认的json存储
+func runCommand(args []string, storeKind, dbPath string) int {
+	if args[0] != "books" || len(args) < 2 || bookCommands[args[1]] == nil {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+
+	c := &cli{storeKind: storeKind, dbPath: dbPath}
+	err := bookCommands[args[1]](c, args[2:])
+	if c.lib != nil {
+		if cerr := c.lib.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	var usage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usage):
+		fmt.Fprintf(os.Stderr, "Error: %v\nRun \"library-management books %s -h\" for usage.\n", err, args[1])
+		return 2
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	return 1
+}
+
+// 创建子命令的FlagSet，并加上所有命令都有的-data和-output
+func (c *cli) flagSet(name, args string) *flag.FlagSet {
+	fset := flag.NewFlagSet("books "+name, flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "Usage: library-management books %s %s\n", name, args)
+		fset.PrintDefaults()
+	}
+	fset.StringVar(&c.data, "data", "", "JSON data file to read and save, instead of -store")
+	fset.StringVar(&c.output, "output", outputTable, "output format: table or json")
+	return fset
+}
+
+// 解析参数(参数和flag可以交错)，检查位置参数的个数(-1表示不限)，然后打开图书馆
+func (c *cli) parse(fset *flag.FlagSet, args []string, nargs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fset.Parse(args); err != nil {
+			return nil, err
+		}
+		if fset.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fset.Arg(0))
+		args = fset.Args()[1:]
+	}
+
+	if nargs >= 0 && len(positional) != nargs {
+		return nil, usageError{fmt.Sprintf("expected %d arguments, got %d", nargs, len(positional))}
+	}
+	if c.output != outputTable && c.output != outputJSON {
+		return nil, usageError{fmt.Sprintf("unknown output format %q, use table or json", c.output)}
+	}
+	return positional, c.open()
+}
+
+// 打开-data指定的文件(不存在时从空的图书馆开始)，或者-store指定的存储(默认json)
+func (c *cli) open() error {
+	switch {
+	case c.data != "" && c.storeKind != "" && c.storeKind != store.Memory:
+		return usageError{"use either -data or -store, not both"}
+	case c.data != "":
+		c.lib = library.NewLibrary()
+		err := fileio.LoadFromFile(c.lib, c.data)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // 第一次保存时创建
+		}
+		return err
+	case c.storeKind == store.Memory:
+		return usageError{"no data to work on, use -data FILE or -store json|wal|sqlite"}
+	}
+
+	kind := c.storeKind
+	if kind == "" {
+		kind = store.JSON
+	}
+	lib, err := openLibrary(kind, c.dbPath)
+	if err != nil {
+		return err
+	}
+	c.lib = lib
+	return nil
+}
+
+// 修改之后保存，使用-store时每次修改已经自动保存
+func (c *cli) save() error {
+	if c.data == "" {
+		return nil
+	}
+	return fileio.SaveToFile(c.lib, c.data)
+}
+
+// 按照-output打印：json时把v编码为JSON，table时调用table
+func (c *cli) print(v any, table func()) error {
+	if c.output == outputTable {
+		table()
+		return nil
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// 打印一本书
+func (c *cli) printBook(book models.Book) error {
+	return c.print(api.NewBook(book), book.PrintDetails)
+}
+
+// 打印多本书，table时每本书一行
+func (c *cli) printBooks(books []models.Book) error {
+	out := make([]api.Book, 0, len(books))
+	for _, book := range books {
+		out = append(out, api.NewBook(book))
+	}
+	return c.print(out, func() { printTable(books) })
+}
+
+func printTable(books []models.Book) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tAUTHOR\tISBN\tCATEGORY\tPRICE")
+	for _, b := range books {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%.2f\n", b.ID, b.Title, b.Author, b.ISBN, b.Category, b.Price)
+	}
+	w.Flush()
+}
+
+// 书籍字段的flag，add和update共用
+type bookFlags struct {
+	title, author, isbn, category *string
+	price                         *float64
+}
+
+func addBookFlags(fset *flag.FlagSet) bookFlags {
+	return bookFlags{
+		title:    fset.String("title", "", "title of the book"),
+		author:   fset.String("author", "", "author of the book"),
+		isbn:     fset.String("isbn", "", "ISBN-10 or ISBN-13"),
+		category: fset.String("category", models.CategoryNames[0], "category name or number: "+strings.Join(models.CategoryNames, ", ")),
+		price:    fset.Float64("price", 0, "price of the book"),
+	}
+}
+
+// 把命令行中给出的字段写到book中，没有给出的字段保持不变
+func (f bookFlags) apply(fset *flag.FlagSet, book *models.Book) error {
+	var err error
+	fset.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "title":
+			book.Title = *f.title
+		case "author":
+			book.Author = *f.author
+		case "isbn":
+			book.ISBN = *f.isbn
+		case "category":
+			var cerr error
+			if book.Category, cerr = models.ParseCategory(*f.category); cerr != nil {
+				err = usageError{cerr.Error()}
+			}
+		case "price":
+			book.Price = *f.price
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(book.Title) == "" {
+		return usageError{"title is required"}
+	}
+	if book.Price < 0 {
+		return usageError{"price must not be negative"}
+	}
+	return nil
+}
+
+func booksAdd(c *cli, args []string) error {
+	fset := c.flagSet("add", "-title T [flags]")
+	fields := addBookFlags(fset)
+	if _, err := c.parse(fset, args, 0); err != nil {
+		return err
+	}
+
+	var book models.Book
+	if err := fields.apply(fset, &book); err != nil {
+		return err
+	}
+	id, err := c.lib.AddBook(book.Title, book.Author, book.ISBN, book.Category, book.Price)
+	if err != nil {
+		return err
+	}
+	if err := c.save(); err != nil {
+		return err
+	}
+	book, _ = c.lib.GetBook(id)
+	return c.printBook(book)
+}
+
+func booksList(c *cli, args []string) error {
+	fset := c.flagSet("list", "[flags]")
+	sortBy := fset.String("sort", "id", "sort by id, title, author or price")
+	desc := fset.Bool("desc", false, "sort in descending order")
+	if _, err := c.parse(fset, args, 0); err != nil {
+		return err
+	}
+
+	field, err := library.ParseSortField(*sortBy)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	return c.printBooks(c.lib.Search(library.Query{SortBy: field, Desc: *desc}).Books)
+}
+
+func booksGet(c *cli, args []string) error {
+	fset := c.flagSet("get", "<id> | -isbn I")
+	isbn := fset.String("isbn", "", "find the book by ISBN instead of ID")
+	positional, err := c.parse(fset, args, -1)
+	if err != nil {
+		return err
+	}
+
+	var book models.Book
+	var exists bool
+	switch {
+	case *isbn != "" && len(positional) == 0:
+		book, exists = c.lib.GetBookByISBN(*isbn)
+	case *isbn == "" && len(positional) == 1:
+		id, err := parseID(positional[0])
+		if err != nil {
+			return err
+		}
+		book, exists = c.lib.GetBook(id)
+	default:
+		return usageError{"give either a book ID or -isbn"}
+	}
+	if !exists {
+		return library.ErrBookNotFound
+	}
+	return c.printBook(book)
+}
+
+func booksUpdate(c *cli, args []string) error {
+	fset := c.flagSet("update", "<id> [flags]")
+	fields := addBookFlags(fset)
+	positional, err := c.parse(fset, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	book, exists := c.lib.GetBook(id)
+	if !exists {
+		return library.ErrBookNotFound
+	}
+	if err := fields.apply(fset, &book); err != nil {
+		return err
+	}
+	if err := c.lib.UpdateBook(id, book.Title, book.Author, book.ISBN, book.Category, book.Price); err != nil {
+		return err
+	}
+	if err := c.save(); err != nil {
+		return err
+	}
+	book, _ = c.lib.GetBook(id)
+	return c.printBook(book)
+}
+
+func booksDelete(c *cli, args []string) error {
+	fset := c.flagSet("delete", "<id>")
+	positional, err := c.parse(fset, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	if err := c.lib.DeleteBook(id); err != nil {
+		return err
+	}
+	if err := c.save(); err != nil {
+		return err
+	}
+	return c.print(map[string]int{"deleted": id}, func() {
+		fmt.Printf("Book %d deleted\n", id)
+	})
+}
+
+func booksSearch(c *cli, args []string) error {
+	fset := c.flagSet("search", "[-q text | text...] [flags]")
+	text := fset.String("q", "", "text to find in the title or author, or an ISBN")
+	tokens := fset.Bool("tokens", false, "match each word separately, in any order")
+	category := fset.String("category", "", "only books in this category")
+	minPrice := fset.Float64("min-price", 0, "minimum price, 0 for no limit")
+	maxPrice := fset.Float64("max-price", 0, "maximum price, 0 for no limit")
+	sortBy := fset.String("sort", "id", "sort by id, title, author or price")
+	desc := fset.Bool("desc", false, "sort in descending order")
+	page := fset.Int("page", 1, "page number, starting from 1")
+	pageSize := fset.Int("page-size", 0, "books per page, 0 for all")
+	positional, err := c.parse(fset, args, -1)
+	if err != nil {
+		return err
+	}
+
+	query := library.Query{
+		Text:     *text,
+		Tokens:   *tokens,
+		MinPrice: *minPrice,
+		MaxPrice: *maxPrice,
+		Desc:     *desc,
+		Page:     *page,
+		PageSize: *pageSize,
+	}
+	if query.Text == "" {
+		query.Text = strings.Join(positional, " ")
+	} else if len(positional) > 0 {
+		return usageError{"give the search text either with -q or as arguments"}
+	}
+	if *category != "" {
+		parsed, err := models.ParseCategory(*category)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		query.Category = &parsed
+	}
+	if query.SortBy, err = library.ParseSortField(*sortBy); err != nil {
+		return usageError{err.Error()}
+	}
+	if query.Page < 1 || query.PageSize < 0 {
+		return usageError{"page must be at least 1 and page-size must not be negative"}
+	}
+
+	result := c.lib.Search(query)
+	list := api.BookList{
+		Books:    make([]api.Book, 0, len(result.Books)),
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: query.PageSize,
+		Pages:    result.Pages,
+	}
+	for _, book := range result.Books {
+		list.Books = append(list.Books, api.NewBook(book))
+	}
+	return c.print(list, func() {
+		printTable(result.Books)
+		fmt.Printf("page %d of %d, %d books found\n", result.Page, result.Pages, result.Total)
+	})
+}
+
+// 导入的JSON输出
+type importOutput struct {
+	Records int           `json:"records"`
+	Invalid int           `json:"invalid"`
+	DryRun  bool          `json:"dry_run"`
+	Added   []int         `json:"added"`
+	Errors  []importError `json:"errors"`
+}
+
+type importError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func booksImport(c *cli, args []string) error {
+	fset := c.flagSet("import", "[flags] <file>")
+	format := fset.String("format", "", "csv or marc (default from the file extension)")
+	mapping := fset.String("map", "", "CSV column mapping, e.g. title=Book Name,price=Cost")
+	dryRun := fset.Bool("dry-run", false, "only check the file, do not add any books")
+	positional, err := c.parse(fset, args, 1)
+	if err != nil {
+		return err
+	}
+
+	opts := fileio.ImportOptions{DryRun: *dryRun}
+	if opts.Format, err = fileFormat(*format, positional[0]); err != nil {
+		return err
+	}
+	if opts.Mapping, err = fileio.ParseMapping(*mapping); err != nil {
+		return usageError{err.Error()}
+	}
+	report, err := fileio.ImportBooks(c.lib, positional[0], opts)
+	if err != nil {
+		return err
+	}
+	if len(report.Added) > 0 {
+		if err := c.save(); err != nil {
+			return err
+		}
+	}
+
+	out := importOutput{Records: len(report.Rows), Invalid: report.Invalid(), DryRun: report.DryRun, Added: report.Added, Errors: []importError{}}
+	if out.Added == nil {
+		out.Added = []int{}
+	}
+	for _, row := range report.Rows {
+		for _, msg := range row.Errors {
+			out.Errors = append(out.Errors, importError{Line: row.Line, Error: msg})
+		}
+	}
+	if err := c.print(out, report.Print); err != nil {
+		return err
+	}
+	if !report.Valid() {
+		return fmt.Errorf("%d invalid records, nothing imported", report.Invalid())
+	}
+	return nil
+}
+
+func booksExport(c *cli, args []string) error {
+	fset := c.flagSet("export", "[flags] <file>")
+	format := fset.String("format", "", "csv or marc (default from the file extension)")
+	mapping := fset.String("map", "", "CSV column mapping, e.g. title=Book Name,price=Cost")
+	positional, err := c.parse(fset, args, 1)
+	if err != nil {
+		return err
+	}
+
+	opts := fileio.ExportOptions{}
+	if opts.Format, err = fileFormat(*format, positional[0]); err != nil {
+		return err
+	}
+	if opts.Mapping, err = fileio.ParseMapping(*mapping); err != nil {
+		return usageError{err.Error()}
+	}
+	if err := fileio.ExportBooks(c.lib, positional[0], opts); err != nil {
+		return err
+	}
+	count := len(c.lib.ListBooks())
+	return c.print(map[string]any{"exported": count, "file": positional[0]}, func() {
+		fmt.Printf("%d books exported to %s\n", count, positional[0])
+	})
+}
+
+// 文件格式，没有指定时按扩展名判断，.mrc和.marc是MARC，其余的是CSV
+func fileFormat(format, filename string) (fileio.Format, error) {
+	switch strings.ToLower(format) {
+	case string(fileio.CSV):
+		return fileio.CSV, nil
+	case string(fileio.MARC):
+		return fileio.MARC, nil
+	case "":
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".mrc", ".marc":
+			return fileio.MARC, nil
+		}
+		return fileio.CSV, nil
+	}
+	return "", usageError{fmt.Sprintf("unknown format %q, use csv or marc", format)}
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, usageError{fmt.Sprintf("invalid book ID %q", s)}
+	}
+	return id, nil
+}
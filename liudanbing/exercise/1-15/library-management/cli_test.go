@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io"
+	"library-management/store"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 运行一个命令，返回退出码和标准输出、标准错误的内容
+func run(t *testing.T, storeKind, dbPath string, args ...string) (int, string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	code := runCommand(args, storeKind, dbPath)
+	os.Stdout, os.Stderr = stdout, stderr
+	w.Close()
+	return code, <-output
+}
+
+func TestBooksCommands(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "books.json")
+	csvFile := filepath.Join(dir, "export.csv")
+	badCSV := filepath.Join(dir, "bad.csv")
+	os.WriteFile(badCSV, []byte("title,price\n,1\nOk,cheap\n"), 0644)
+
+	for _, tc := range []struct {
+		args string
+		code int
+		want []string // 按顺序出现在输出中
+	}{
+		{"add -title Dune -author Herbert -isbn 0306406152 -category fiction -price 10", 0, []string{"Dune", "Herbert"}},
+		{"add -title Go -category Computer -price 40 -output json", 0, []string{`"id": 2`, `"category": "Computer"`}},
+		{"add -author Nobody", 2, []string{"title is required"}},
+		{"add -title Copy -isbn 9780306406157", 1, []string{"already exists"}},
+		{"add -title Bad -category poetry", 2, []string{"unknown category"}},
+		{"list", 0, []string{"ID", "1", "Dune", "2", "Go"}},
+		{"list -sort price -desc", 0, []string{"Go", "Dune"}},
+		{"list -sort isbn", 2, []string{"unknown sort field"}},
+		{"get 1", 0, []string{"Dune"}},
+		{"get -isbn 0-306-40615-2 -output json", 0, []string{`"isbn": "9780306406157"`, `"isbn10": "0306406152"`}},
+		{"get 99", 1, []string{"book not found"}},
+		{"get abc", 2, []string{"invalid book ID"}},
+		{"get", 2, []string{"either a book ID or -isbn"}},
+		{"update 2 -price 45 -output json", 0, []string{`"title": "Go"`, `"price": 45`}},
+		{"update 99 -price 1", 1, []string{"book not found"}},
+		{"search go", 0, []string{"Go", "page 1 of 1, 1 books found"}},
+		{"search -sort price -page-size 1 -page 2", 0, []string{"Go", "page 2 of 2, 2 books found"}},
+		{"search -q dune extra", 2, []string{"either with -q or as arguments"}},
+		{"search -page 0", 2, []string{"page must be at least 1"}},
+		{"export " + csvFile, 0, []string{"2 books exported"}},
+		{"import -dry-run " + csvFile, 1, []string{"line 2: ISBN 9780306406157 already belongs to book 1", "1 valid, 1 invalid, dry run"}},
+		{"import -output json " + badCSV, 1, []string{`"invalid": 2`, `"line": 2`, `"line": 3`}},
+		{"import -format xml " + badCSV, 2, []string{"unknown format"}},
+		{"delete 2", 0, []string{"Book 2 deleted"}},
+		{"get 2", 1, []string{"book not found"}},
+		{"delete 2", 1, []string{"book not found"}},
+		{"delete", 2, []string{"expected 1 arguments, got 0"}},
+		{"list -h", 0, []string{"Usage: library-management books list"}},
+		{"list -output yaml", 2, []string{"unknown output format"}},
+	} {
+		args := append(strings.Fields("books "+tc.args), "-data", data)
+		code, out := run(t, "", "", args...)
+		if code != tc.code {
+			t.Errorf("%s: exit code %d, want %d\n%s", tc.args, code, tc.code, out)
+			continue
+		}
+		rest := out
+		for _, want := range tc.want {
+			i := strings.Index(rest, want)
+			if i < 0 {
+				t.Errorf("%s: %q not found in\n%s", tc.args, want, out)
+				break
+			}
+			rest = rest[i+len(want):]
+		}
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	for _, args := range [][]string{{"books"}, {"books", "rename"}, {"members", "list"}} {
+		if code, out := run(t, "", "", args...); code != 2 || !strings.Contains(out, "Usage:") {
+			t.Errorf("%v: exit code %d\n%s", args, code, out)
+		}
+	}
+
+	data := filepath.Join(t.TempDir(), "books.json")
+	if code, out := run(t, store.SQLite, "", "books", "list", "-data", data); code != 2 || !strings.Contains(out, "either -data or -store") {
+		t.Errorf("-data with -store sqlite: exit code %d\n%s", code, out)
+	}
+	if code, out := run(t, store.Memory, "", "books", "list"); code != 2 || !strings.Contains(out, "no data to work on") {
+		t.Errorf("memory store without -data: exit code %d\n%s", code, out)
+	}
+}
+
+// 没有-data时修改自动保存到-store指定的存储，默认是json
+func TestCommandsWithStore(t *testing.T) {
+	for _, kind := range []string{"", store.WAL, store.SQLite} {
+		path := filepath.Join(t.TempDir(), "library.db")
+		if code, out := run(t, kind, path, "books", "add", "-title", "Dune"); code != 0 {
+			t.Fatalf("store %q: add exit code %d\n%s", kind, code, out)
+		}
+		code, out := run(t, kind, path, "books", "list")
+		if code != 0 || !strings.Contains(out, "Dune") {
+			t.Errorf("store %q: list exit code %d\n%s", kind, code, out)
+		}
+		if kind == "" && !strings.Contains(out, "Using json store") {
+			t.Errorf("default store is not json:\n%s", out)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package fileio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Backups 是SaveToFile保留的备份数，备份文件为 filename.1(最新) 到 filename.N
+var Backups = 3
+
+// 原子地写入文件：先写到同一目录下的临时文件并fsync，再改名覆盖原文件，
+// 任何时候崩溃，filename要么是旧的内容，要么是新的内容。
+// backups大于0时，覆盖之前把原文件轮换到 filename.1 ... filename.backups
+// 新文件沿用原文件的权限，原文件不存在时为0644
+func writeFileAtomic(filename string, backups int, write func(w io.Writer) error) error {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(filename); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(dir, base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 改名成功之后这里什么都不做
+
+	if err := tmp.Chmod(mode); err != nil { // CreateTemp创建的文件是0600
+		tmp.Close()
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if backups > 0 {
+		if err := rotateBackups(filename, backups); err != nil {
+			return fmt.Errorf("rotate backups: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// 把 filename.i 改名为 filename.i+1，原文件保留一份到 filename.1，
+// 原文件一直存在，轮换到一半崩溃也不会丢失数据
+func rotateBackups(filename string, backups int) error {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil
+	}
+	for i := backups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", filename, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", filename, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return copyFile(filename, filename+".1")
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// fsync目录，保证改名被写到磁盘上；有的系统不支持打开目录，忽略错误
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,228 @@
+package fileio
+
+import (
+	"fmt"
+	"library-management/library"
+	"library-management/models"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format 是批量导入导出的文件格式
+type Format string
+
+const (
+	CSV  Format = "csv"  // 第一行是表头的CSV
+	MARC Format = "marc" // 简化的MARC行格式，见marc.go
+)
+
+// 导入导出的书籍字段
+const (
+	FieldTitle    = "title"
+	FieldAuthor   = "author"
+	FieldISBN     = "isbn"
+	FieldCategory = "category"
+	FieldPrice    = "price"
+)
+
+// Fields 是全部的字段，也是导出时列的顺序
+var Fields = []string{FieldTitle, FieldAuthor, FieldISBN, FieldCategory, FieldPrice}
+
+// ImportOptions 是导入的选项
+type ImportOptions struct {
+	Format  Format
+	Mapping ColumnMapping // 只用于CSV
+	DryRun  bool          // 只检查，不添加到图书馆
+}
+
+// RowResult 是导入时一行(一条记录)的检查结果
+type RowResult struct {
+	Line   int // 记录在文件中的行号
+	Book   models.Book
+	Errors []string
+}
+
+// ImportReport 是导入的报告
+type ImportReport struct {
+	Rows   []RowResult
+	Added  []int // 添加的书的ID，DryRun或者有错误时为空
+	DryRun bool
+}
+
+// Valid 表示所有的记录都通过了检查
+func (r ImportReport) Valid() bool {
+	return r.Invalid() == 0
+}
+
+// Invalid 返回没有通过检查的记录数
+func (r ImportReport) Invalid() int {
+	n := 0
+	for _, row := range r.Rows {
+		if len(row.Errors) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Print 打印报告，只列出有错误的记录
+func (r ImportReport) Print() {
+	for _, row := range r.Rows {
+		for _, err := range row.Errors {
+			fmt.Printf("line %d: %s\n", row.Line, err)
+		}
+	}
+	fmt.Printf("%d records, %d valid, %d invalid", len(r.Rows), len(r.Rows)-r.Invalid(), r.Invalid())
+	switch {
+	case r.DryRun:
+		fmt.Println(", dry run: nothing imported")
+	case len(r.Added) > 0:
+		fmt.Printf(", %d books imported\n", len(r.Added))
+	default:
+		fmt.Println(", nothing imported")
+	}
+}
+
+// 从文件中读出的一条记录：字段名 -> 值
+type record struct {
+	line   int
+	fields map[string]string
+}
+
+// ImportBooks 从文件中导入书籍：先检查所有的记录，全部通过并且不是DryRun时，
+// 在一个事务中添加到图书馆，有一条记录不合法就一本都不添加
+func ImportBooks(lib *library.Library, filename string, opts ImportOptions) (ImportReport, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	defer file.Close()
+
+	var records []record
+	switch opts.Format {
+	case CSV, "":
+		records, err = readCSV(file, opts.Mapping)
+	case MARC:
+		records, err = readMARC(file)
+	default:
+		err = fmt.Errorf("unknown format %q", opts.Format)
+	}
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := ImportReport{DryRun: opts.DryRun}
+	seen := make(map[string]int) // ISBN -> 行号，检查文件中的重复
+	for _, rec := range records {
+		row := checkRecord(lib, rec, seen)
+		report.Rows = append(report.Rows, row)
+	}
+	if opts.DryRun || !report.Valid() {
+		return report, nil
+	}
+
+	books := make([]models.Book, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		books = append(books, row.Book)
+	}
+	// 检查之后图书馆可能又被修改了，AddBooks会再检查一次
+	report.Added, err = lib.AddBooks(books)
+	return report, err
+}
+
+// 检查一条记录并转换成Book
+func checkRecord(lib *library.Library, rec record, seen map[string]int) RowResult {
+	row := RowResult{Line: rec.line}
+	fail := func(format string, args ...any) {
+		row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+	}
+
+	row.Book.Title = strings.TrimSpace(rec.fields[FieldTitle])
+	if row.Book.Title == "" {
+		fail("title is required")
+	}
+	row.Book.Author = strings.TrimSpace(rec.fields[FieldAuthor])
+
+	if s := strings.TrimSpace(rec.fields[FieldCategory]); s != "" {
+		category, err := models.ParseCategory(s)
+		if err != nil {
+			fail("%v", err)
+		}
+		row.Book.Category = category
+	}
+
+	if s := strings.TrimSpace(rec.fields[FieldPrice]); s != "" {
+		price, err := strconv.ParseFloat(s, 64)
+		if err != nil || price < 0 {
+			fail("invalid price %q", s)
+		}
+		row.Book.Price = price
+	}
+
+	if s := strings.TrimSpace(rec.fields[FieldISBN]); s != "" {
+		isbn, err := models.NormalizeISBN(s)
+		switch {
+		case err != nil:
+			fail("invalid ISBN %q", s)
+		case seen[isbn] != 0:
+			fail("ISBN %s is also on line %d", isbn, seen[isbn])
+		default:
+			if book, exists := lib.GetBookByISBN(isbn); exists {
+				fail("ISBN %s already belongs to book %d (%s)", isbn, book.ID, book.Title)
+			}
+			seen[isbn] = rec.line
+		}
+		row.Book.ISBN = isbn
+	}
+	return row
+}
+
+// ExportOptions 是导出的选项
+type ExportOptions struct {
+	Format  Format
+	Mapping ColumnMapping // 只用于CSV
+}
+
+// ExportBooks 把全部书籍按ID顺序导出到文件
+func ExportBooks(lib *library.Library, filename string, opts ExportOptions) error {
+	books := lib.ListBooks()
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch opts.Format {
+	case CSV, "":
+		err = writeCSV(file, books, opts.Mapping)
+	case MARC:
+		err = writeMARC(file, books)
+	default:
+		err = fmt.Errorf("unknown format %q", opts.Format)
+	}
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// 导出时字段的值
+func fieldValue(book models.Book, field string) string {
+	switch field {
+	case FieldTitle:
+		return book.Title
+	case FieldAuthor:
+		return book.Author
+	case FieldISBN:
+		return book.ISBN
+	case FieldCategory:
+		return book.Category.String()
+	case FieldPrice:
+		return strconv.FormatFloat(book.Price, 'f', 2, 64)
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+package fileio
+
+import (
+	"library-management/library"
+	"library-management/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// 把content写到临时文件中，返回文件名
+func writeTemp(t *testing.T, name, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// 按ID顺序返回全部的书
+func sortedBooks(lib *library.Library) []models.Book {
+	books := lib.ListBooks()
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	return books
+}
+
+func TestImportCSV(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		csv     string
+		mapping string
+		errors  map[int]string // 行号 -> 错误信息中的内容
+		added   int
+	}{
+		{
+			name: "valid",
+			csv: "title,author,isbn,category,price\n" +
+				"Dune,Herbert,0-306-40615-2,fiction,10.5\n" +
+				"\"Go, the language\",Donovan,,4,40\n",
+			added: 2,
+		},
+		{
+			name:    "mapped columns in any order",
+			csv:     "Cost,Book Name,Extra\n9.99,Dune,ignored\n",
+			mapping: "price=Cost,title=Book Name",
+			added:   1,
+		},
+		{
+			name: "invalid rows",
+			csv: "title,isbn,category,price\n" +
+				",,,\n" +
+				"Dune,0306406153,,\n" +
+				"Dune,,poetry,\n" +
+				"Dune,,,-1\n" +
+				"Dune,,,cheap\n" +
+				"Ok,,,1\n",
+			errors: map[int]string{
+				2: "title is required",
+				3: "invalid ISBN",
+				4: "unknown category",
+				5: "invalid price",
+				6: "invalid price",
+			},
+		},
+		{
+			name: "duplicate ISBN in the file and in the library",
+			csv: "title,isbn\n" +
+				"A,0306406152\n" +
+				"B,978-0-306-40615-7\n" +
+				"C,9780134190440\n",
+			errors: map[int]string{3: "also on line 2", 4: "already belongs to book 1"},
+		},
+	} {
+		lib := library.NewLibrary()
+		lib.AddBook("Go", "Donovan", "9780134190440", models.Computer, 40)
+		mapping, err := ParseMapping(tc.mapping)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := ImportBooks(lib, writeTemp(t, "books.csv", tc.csv), ImportOptions{Format: CSV, Mapping: mapping})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if report.Invalid() != len(tc.errors) {
+			t.Errorf("%s: %d invalid rows, want %d: %+v", tc.name, report.Invalid(), len(tc.errors), report.Rows)
+		}
+		for _, row := range report.Rows {
+			want, bad := tc.errors[row.Line]
+			if bad != (len(row.Errors) > 0) || (bad && !strings.Contains(strings.Join(row.Errors, "; "), want)) {
+				t.Errorf("%s: line %d errors %q, want %q", tc.name, row.Line, row.Errors, want)
+			}
+		}
+		// 有一行不合法就一本都不添加
+		if len(report.Added) != tc.added || len(lib.ListBooks()) != 1+tc.added {
+			t.Errorf("%s: added %v, library has %d books", tc.name, report.Added, len(lib.ListBooks()))
+		}
+	}
+}
+
+func TestImportCSVHeader(t *testing.T) {
+	for _, tc := range []struct {
+		csv, mapping, want string
+	}{
+		{"", "", "empty CSV file"},
+		{"name,author\nDune,Herbert\n", "", `no "title" column`},
+		{"title,author\nDune,Herbert\n", "title=Name", `no "Name" column`},
+	} {
+		mapping, _ := ParseMapping(tc.mapping)
+		_, err := ImportBooks(library.NewLibrary(), writeTemp(t, "books.csv", tc.csv), ImportOptions{Mapping: mapping})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: got %v, want %q", tc.csv, err, tc.want)
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want ColumnMapping
+	}{
+		{"", ColumnMapping{}},
+		{"title=Book Name, PRICE = Cost", ColumnMapping{FieldTitle: "Book Name", FieldPrice: "Cost"}},
+		{"title", nil},
+		{"color=Colour", nil},
+		{"title=", nil},
+	} {
+		got, err := ParseMapping(tc.in)
+		if (tc.want == nil) != (err != nil) || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseMapping(%q) = %v, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestImportMARC(t *testing.T) {
+	marc := `=LDR  book
+=001  7
+=020  $a0306406152
+=100  $aHerbert, Frank
+=245  $aDune
+=084  $aFiction
+=365  $b10.50
+
+=LDR  book
+=245  $aNo price
+=999  $aunknown tags are ignored
+`
+	lib := library.NewLibrary()
+	report, err := ImportBooks(lib, writeTemp(t, "books.mrc", marc), ImportOptions{Format: MARC})
+	if err != nil || !report.Valid() || len(report.Added) != 2 {
+		t.Fatalf("ImportBooks = %+v, %v", report, err)
+	}
+	want := []models.Book{
+		{ID: 1, Title: "Dune", Author: "Herbert, Frank", ISBN: "9780306406157", Category: models.Fiction, Price: 10.5},
+		{ID: 2, Title: "No price"},
+	}
+	if got := sortedBooks(lib); !reflect.DeepEqual(got, want) {
+		t.Errorf("imported %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{
+		"=245  $aDune\n",                       // 记录要以=LDR开始
+		"=LDR  book\nDune\n",                   // 不是=TAG
+		"=LDR  book\n=245  $aA\n\n=100  $aB\n", // 空行之后没有=LDR
+	} {
+		if _, err := ImportBooks(library.NewLibrary(), writeTemp(t, "bad.mrc", bad), ImportOptions{Format: MARC}); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+
+	report, err = ImportBooks(library.NewLibrary(), writeTemp(t, "invalid.mrc", "=LDR  book\n=020  $a123\n"), ImportOptions{Format: MARC})
+	if err != nil || report.Invalid() != 1 || report.Rows[0].Line != 1 {
+		t.Errorf("invalid record: %+v, %v", report, err)
+	}
+}
+
+// 只检查不导入
+func TestImportDryRun(t *testing.T) {
+	lib := library.NewLibrary()
+	filename := writeTemp(t, "books.csv", "title,price\nDune,10\nGo,40\n")
+
+	report, err := ImportBooks(lib, filename, ImportOptions{DryRun: true})
+	if err != nil || !report.Valid() || !report.DryRun || len(report.Rows) != 2 {
+		t.Fatalf("dry run = %+v, %v", report, err)
+	}
+	if len(report.Added) != 0 || len(lib.ListBooks()) != 0 {
+		t.Errorf("dry run added %v, library has %d books", report.Added, len(lib.ListBooks()))
+	}
+
+	report, err = ImportBooks(lib, filename, ImportOptions{})
+	if err != nil || !reflect.DeepEqual(report.Added, []int{1, 2}) {
+		t.Errorf("import after the dry run = %+v, %v", report, err)
+	}
+}
+
+// 导出之后再导入得到相同的书
+func TestExportImport(t *testing.T) {
+	lib := library.NewLibrary()
+	lib.AddBook("Dune", "Herbert, Frank", "0306406152", models.Fiction, 10.5)
+	lib.AddBook("Go $ \"quoted\"", "Donovan", "", models.Computer, 40)
+	lib.AddBook("Cosmos", "", "9780134190440", models.Science, 0)
+
+	for _, tc := range []struct {
+		name string
+		opts ExportOptions
+	}{
+		{"books.csv", ExportOptions{Format: CSV}},
+		{"mapped.csv", ExportOptions{Format: CSV, Mapping: ColumnMapping{FieldTitle: "Name"}}},
+		{"books.mrc", ExportOptions{Format: MARC}},
+	} {
+		filename := filepath.Join(t.TempDir(), tc.name)
+		if err := ExportBooks(lib, filename, tc.opts); err != nil {
+			t.Fatal(err)
+		}
+		imported := library.NewLibrary()
+		report, err := ImportBooks(imported, filename, ImportOptions{Format: tc.opts.Format, Mapping: tc.opts.Mapping})
+		if err != nil || !report.Valid() {
+			t.Fatalf("%s: import = %+v, %v", tc.name, report, err)
+		}
+
+		want := sortedBooks(lib)
+		if tc.opts.Format == MARC {
+			want[1].Title = `Go  "quoted"` // MARC中$是子字段的分隔符，导出时去掉
+		}
+		if got := sortedBooks(imported); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package fileio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"library-management/models"
+	"strings"
+)
+
+// ColumnMapping 是字段到CSV表头的映射，例如 title -> "Book Name"，
+// 没有映射的字段使用字段名作为表头，表头不区分大小写
+type ColumnMapping map[string]string
+
+// ParseMapping 解析 "title=Book Name,price=Cost" 形式的映射，空字符串表示没有映射
+func ParseMapping(s string) (ColumnMapping, error) {
+	mapping := make(ColumnMapping)
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || !isField(field) || strings.TrimSpace(column) == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, use field=column with fields %s",
+				pair, strings.Join(Fields, ", "))
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
+
+func isField(name string) bool {
+	for _, f := range Fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Column 返回字段对应的表头
+func (m ColumnMapping) Column(field string) string {
+	if column, ok := m[field]; ok {
+		return column
+	}
+	return field
+}
+
+// 读取CSV，第一行是表头，没有映射到字段的列被忽略
+func readCSV(r io.Reader, mapping ColumnMapping) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // 列数不对的行在检查时报告
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty CSV file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[int]string) // 列号 -> 字段
+	for i, name := range header {
+		for _, field := range Fields {
+			if strings.EqualFold(strings.TrimSpace(name), mapping.Column(field)) {
+				columns[i] = field
+			}
+		}
+	}
+	found := false
+	for _, field := range columns {
+		if field == FieldTitle {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("CSV header has no %q column", mapping.Column(FieldTitle))
+	}
+
+	var records []record
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		rec := record{line: line, fields: make(map[string]string)}
+		for i, value := range values {
+			if field, ok := columns[i]; ok {
+				rec.fields[field] = value
+			}
+		}
+		records = append(records, rec)
+	}
+}
+
+// 写CSV，第一行是表头
+func writeCSV(w io.Writer, books []models.Book, mapping ColumnMapping) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(Fields))
+	for i, field := range Fields {
+		header[i] = mapping.Column(field)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, book := range books {
+		row := make([]string, len(Fields))
+		for i, field := range Fields {
+			row[i] = fieldValue(book, field)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
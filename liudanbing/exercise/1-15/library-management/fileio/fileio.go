@@ -2,52 +2,64 @@ package fileio
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"library-management/library"
-	"library-management/models"
 	"os"
 )
 
 // SaveToFile 将图书馆的图书数据保存到文件中
 func SaveToFile(lib *library.Library, filename string) error {
-	file, err := os.Create(filename) // 创建文件
+	return SaveSnapshot(filename, lib.Snapshot()) // 获取图书馆的全部数据并保存
+}
+
+// LoadFromFile 从文件中加载图书数据到图书馆
+func LoadFromFile(lib *library.Library, filename string) error {
+	snap, err := LoadSnapshot(filename)
 	if err != nil {
 		return err
 	}
-	defer file.Close() // 函数返回前关闭文件
+	return lib.Restore(snap) // 替换图书馆的全部数据，旧文件中没有借阅数据时为空
+}
+
+// SaveSnapshot 把图书馆的全部数据(图书、副本、会员、借阅和预约)保存为JSON文件，
+// 原子地替换原文件并保留Backups个备份
+func SaveSnapshot(filename string, snap library.Snapshot) error {
+	return WriteSnapshot(filename, snap, Backups)
+}
 
-	books, nextID := lib.GetBooks() // 获取图书数据
+// WriteSnapshot 和SaveSnapshot一样，但是保留backups个备份，0表示不备份
+func WriteSnapshot(filename string, snap library.Snapshot, backups int) error {
 	data := struct {
-		Books map[int]models.Book `json:"books"`
-		NextID int `json:"next_id"`
+		SchemaVersion int `json:"schema_version"` // 文件格式的版本，见schema.go
+		library.Snapshot
 	}{
-		Books: books,
-		NextID: nextID,
+		SchemaVersion: SchemaVersion,
+		Snapshot: snap,
 	}
 
-	encoder := json.NewEncoder(file) // 创建JSON编码器
-	encoder.SetIndent("", "    ") // 设置缩进
-	return encoder.Encode(data) // 编码并写入文件
+	return writeFileAtomic(filename, backups, func(w io.Writer) error {
+		encoder := json.NewEncoder(w) // 创建JSON编码器
+		encoder.SetIndent("", "    ") // 设置缩进
+		return encoder.Encode(data) // 编码并写入文件
+	})
 }
 
-// LoadFromFile 从文件中加载图书数据到图书馆
-func LoadFromFile(lib *library.Library, filename string) error {
-	file, err := os.Open(filename)
+// LoadSnapshot 从JSON文件中读取图书馆的全部数据，旧版本的文件先升级到当前版本
+func LoadSnapshot(filename string) (library.Snapshot, error) {
+	data, err := os.ReadFile(filename)
 	if err != nil {
-		return err
+		return library.Snapshot{}, err
 	}
-	defer file.Close()
-
-	var data struct {
-		Books map[int]models.Book `json:"books"`
-		NextID int `json:"next_id"`
+	data, err = migrate(data)
+	if err != nil {
+		return library.Snapshot{}, fmt.Errorf("%s: %w", filename, err)
 	}
 
-	decoder := json.NewDecoder(file) // 创建JSON解码器
-	err = decoder.Decode(&data) // 解码文件内容, 并存入data
+	var snap library.Snapshot
+	err = json.Unmarshal(data, &snap) // 解码文件内容, 并存入snap
 	if err != nil {
-		return err
+		return library.Snapshot{}, fmt.Errorf("%s: %w", filename, err)
 	}
-
-	lib.SetBooks(data.Books, data.NextID) // 设置图书数据
-	return nil
-}
\ No newline at end of file
+	return snap.Clone(), nil // Clone补上旧文件中没有的数据
+}
@@ -0,0 +1,96 @@
+package fileio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"library-management/models"
+	"strings"
+)
+
+// 简化的MARC行格式(类似MARCMaker)，每条记录以 =LDR 开始，记录之间空一行：
+//
+//	=LDR  book
+//	=001  12
+//	=020  $a9780134190440
+//	=100  $aDonovan, Alan
+//	=245  $aThe Go Programming Language
+//	=084  $aComputer
+//	=365  $b30.00
+//
+// 001是书的ID，导入时忽略；每个字段只使用一个子字段
+
+// MARC字段标签和子字段 -> 书籍字段
+var marcTags = []struct {
+	tag      string
+	subfield byte
+	field    string
+}{
+	{"020", 'a', FieldISBN},
+	{"100", 'a', FieldAuthor},
+	{"245", 'a', FieldTitle},
+	{"084", 'a', FieldCategory},
+	{"365", 'b', FieldPrice},
+}
+
+// 读取MARC行格式，不认识的字段被忽略
+func readMARC(r io.Reader) ([]record, error) {
+	var records []record
+	var current *record
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			current = nil
+			continue
+		}
+		if !strings.HasPrefix(text, "=") || len(text) < 4 {
+			return nil, fmt.Errorf("line %d: expected =TAG, got %q", line, text)
+		}
+		tag := text[1:4]
+		value := strings.TrimSpace(text[4:])
+
+		if tag == "LDR" {
+			records = append(records, record{line: line, fields: make(map[string]string)})
+			current = &records[len(records)-1]
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: field =%s outside a record, records start with =LDR", line, tag)
+		}
+		for _, t := range marcTags {
+			if t.tag == tag {
+				current.fields[t.field] = marcSubfield(value, t.subfield)
+			}
+		}
+	}
+	return records, scanner.Err()
+}
+
+// 取出子字段的值，例如 "$aDonovan, Alan" 中的a
+func marcSubfield(value string, code byte) string {
+	for _, part := range strings.Split(value, "$")[1:] {
+		if len(part) > 0 && part[0] == code {
+			return part[1:]
+		}
+	}
+	return ""
+}
+
+// 写MARC行格式，空的字段不写
+func writeMARC(w io.Writer, books []models.Book) error {
+	bw := bufio.NewWriter(w)
+	for i, book := range books {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintln(bw, "=LDR  book")
+		fmt.Fprintf(bw, "=001  %d\n", book.ID)
+		for _, t := range marcTags {
+			if value := fieldValue(book, t.field); value != "" {
+				fmt.Fprintf(bw, "=%s  $%c%s\n", t.tag, t.subfield, strings.ReplaceAll(value, "$", ""))
+			}
+		}
+	}
+	return bw.Flush()
+}
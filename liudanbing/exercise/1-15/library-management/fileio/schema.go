@@ -0,0 +1,54 @@
+package fileio
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion 是当前JSON文件的版本，保存在 schema_version 字段中。
+// 修改文件格式时增加版本号，并在migrations中添加从上一个版本升级的函数；
+// 只是给结构体添加字段时不需要升级，旧文件中没有的字段为零值
+const SchemaVersion = 1
+
+// migrations[i] 把版本i的文件升级到版本i+1，文件按JSON对象处理
+var migrations = []func(doc map[string]any) error{
+	migrateV0,
+}
+
+// 版本0：没有schema_version字段的文件，只有books和next_id。
+// 版本1只是增加了字段(ISBN、副本、会员、借阅和预约)，旧文件中没有的字段为零值，
+// 所以升级时只需要加上版本号
+func migrateV0(doc map[string]any) error {
+	return nil
+}
+
+// 把任意版本的文件升级到当前版本，返回升级之后的JSON
+func migrate(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if v, ok := doc["schema_version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f != float64(int(f)) || f < 0 {
+			return nil, fmt.Errorf("invalid schema_version %v", v)
+		}
+		version = int(f)
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("schema_version %d is newer than this program supports (%d)", version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return data, nil
+	}
+
+	for ; version < SchemaVersion; version++ {
+		if err := migrations[version](doc); err != nil {
+			return nil, fmt.Errorf("migrate from schema_version %d: %w", version, err)
+		}
+	}
+	doc["schema_version"] = SchemaVersion
+	return json.Marshal(doc)
+}
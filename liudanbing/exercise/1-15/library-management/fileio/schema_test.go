@@ -0,0 +1,145 @@
+package fileio
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"library-management/library"
+	"library-management/models"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// 最早的文件格式：只有books和next_id，没有schema_version
+const v0File = `{
+    "books": {
+        "2": {"ID": 2, "Title": "blockchain", "Author": "dylan", "Category": 4, "Price": 9999}
+    },
+    "next_id": 3
+}`
+
+func TestMigrate(t *testing.T) {
+	for _, tc := range []struct {
+		name, in string
+		err      string // 为空表示成功
+	}{
+		{"v0", v0File, ""},
+		{"current", `{"schema_version": 1, "books": {}, "next_id": 1}`, ""},
+		{"newer", `{"schema_version": 2}`, "newer than this program supports"},
+		{"negative", `{"schema_version": -1}`, "invalid schema_version"},
+		{"fraction", `{"schema_version": 1.5}`, "invalid schema_version"},
+		{"string", `{"schema_version": "1"}`, "invalid schema_version"},
+		{"not json", `books`, "invalid character"},
+	} {
+		out, err := migrate([]byte(tc.in))
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: got %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var doc map[string]any
+		json.Unmarshal(out, &doc)
+		if doc["schema_version"] != float64(SchemaVersion) {
+			t.Errorf("%s: schema_version %v after migration", tc.name, doc["schema_version"])
+		}
+	}
+}
+
+// 旧文件升级之后可以加载，新增的数据为空
+func TestLoadV0File(t *testing.T) {
+	snap, err := LoadSnapshot(writeTemp(t, "books.json", v0File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := snap.Books[2]
+	if len(snap.Books) != 1 || book.Title != "blockchain" || book.Category != models.Computer || book.ISBN != "" || snap.NextID != 3 {
+		t.Errorf("loaded %+v", snap)
+	}
+	if len(snap.Members) != 0 || snap.NextMemberID != 1 || snap.NextCopyID != 1 {
+		t.Errorf("circulation of a v0 file: %+v", snap.Circulation)
+	}
+}
+
+func TestSaveBackups(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "books.json")
+	for i := 1; i <= 5; i++ {
+		snap := library.NewSnapshot()
+		snap.NextID = i
+		if err := WriteSnapshot(filename, snap, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// filename是最新的，filename.1到filename.3依次是之前的
+	for suffix, want := range map[string]int{"": 5, ".1": 4, ".2": 3, ".3": 2} {
+		snap, err := LoadSnapshot(filename + suffix)
+		if err != nil || snap.NextID != want {
+			t.Errorf("books.json%s: next_id %d, %v, want %d", suffix, snap.NextID, err, want)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 4 {
+		t.Errorf("%d files after saving, want the file and 3 backups", len(entries))
+	}
+}
+
+// 写入失败时原文件不变，也不留下临时文件
+func TestWriteFileAtomicFailure(t *testing.T) {
+	filename := writeTemp(t, "books.json", "old")
+	errWrite := errors.New("write failed")
+
+	err := writeFileAtomic(filename, 1, func(w io.Writer) error {
+		io.WriteString(w, "half of the new")
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("got %v, want %v", err, errWrite)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "old" {
+		t.Errorf("file changed to %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filename)); len(entries) != 1 {
+		t.Errorf("%d files left after a failed write, want 1", len(entries))
+	}
+}
+
+// 保存之后文件的权限不变，新文件为0644
+func TestWriteFileAtomicMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("windows has no unix permissions")
+	}
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name string
+		mode os.FileMode // 0表示原来没有这个文件
+		want os.FileMode
+	}{
+		{"new.json", 0, 0644},
+		{"private.json", 0600, 0600},
+		{"shared.json", 0664, 0664},
+	} {
+		filename := filepath.Join(dir, tc.name)
+		if tc.mode != 0 {
+			os.WriteFile(filename, []byte("old"), tc.mode)
+			os.Chmod(filename, tc.mode) // 不受umask影响
+		}
+		if err := WriteSnapshot(filename, library.NewSnapshot(), 1); err != nil {
+			t.Fatal(err)
+		}
+		fi, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != tc.want {
+			t.Errorf("%s: mode %v, want %v", tc.name, fi.Mode().Perm(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"sort"
+	"time"
+)
+
+// 借阅相关的错误
+var (
+	ErrBookNotFound    = errors.New("book not found")
+	ErrBookOnLoan      = errors.New("some copies of the book are on loan")
+	ErrBookChanged     = errors.New("book has been changed by someone else")
+	ErrCopyNotFound    = errors.New("copy not found")
+	ErrLoanNotFound    = errors.New("loan not found")
+	ErrNoCopyAvailable = errors.New("no copy available")
+	ErrNotOnLoan       = errors.New("copy is not on loan")
+	ErrRenewLimit      = errors.New("renewal limit reached")
+	ErrOverdue         = errors.New("loan is overdue")
+	ErrHasFines        = errors.New("member has unpaid fines")
+	ErrLoanLimit       = errors.New("member has reached the loan limit")
+	ErrHoldPending     = errors.New("other members are waiting for this book")
+	ErrInvalidAmount   = errors.New("amount must be greater than zero")
+)
+
+// TierRule 是一个会员等级的借阅规则
+type TierRule struct {
+	MaxLoans    int // 最多同时借的书
+	LoanDays    int // 借期(天)
+	MaxRenewals int // 最多续借的次数
+}
+
+// Policy 是借阅规则
+type Policy struct {
+	Tiers      map[models.Tier]TierRule // 每个会员等级的规则
+	FinePerDay float64                  // 逾期每天的罚款
+	MaxFines   float64                  // 未缴罚款超过这个数时不能再借书，0表示不限制
+	PickupDays int                      // 预约的书到了之后保留多少天
+}
+
+// DefaultPolicy 返回默认的借阅规则
+func DefaultPolicy() Policy {
+	return Policy{
+		Tiers: map[models.Tier]TierRule{
+			models.Basic:    {MaxLoans: 3, LoanDays: 14, MaxRenewals: 1},
+			models.Standard: {MaxLoans: 5, LoanDays: 21, MaxRenewals: 2},
+			models.Premium:  {MaxLoans: 10, LoanDays: 28, MaxRenewals: 3},
+		},
+		FinePerDay: 0.5,
+		MaxFines:   10,
+		PickupDays: 3,
+	}
+}
+
+// Rule 返回会员适用的借阅规则，会员单独设置的借阅上限优先
+func (p Policy) Rule(m models.Member) TierRule {
+	rule := p.Tiers[m.Tier]
+	if m.MaxLoans > 0 {
+		rule.MaxLoans = m.MaxLoans
+	}
+	return rule
+}
+
+// Circulation 保存副本、会员、借阅和预约记录，和Books一起保存到文件
+type Circulation struct {
+	Copies       map[int]models.Copy   `json:"copies"`
+	Members      map[int]models.Member `json:"members"`
+	Loans        map[int]models.Loan   `json:"loans"`
+	Holds        map[int]models.Hold   `json:"holds"`
+	NextCopyID   int                   `json:"next_copy_id"`
+	NextMemberID int                   `json:"next_member_id"`
+	NextLoanID   int                   `json:"next_loan_id"`
+	NextHoldID   int                   `json:"next_hold_id"`
+}
+
+func newCirculation() Circulation {
+	return Circulation{
+		Copies:       make(map[int]models.Copy),
+		Members:      make(map[int]models.Member),
+		Loans:        make(map[int]models.Loan),
+		Holds:        make(map[int]models.Hold),
+		NextCopyID:   1,
+		NextMemberID: 1,
+		NextLoanID:   1,
+		NextHoldID:   1,
+	}
+}
+
+// 复制一份，避免外部修改map
+func (c Circulation) clone() Circulation {
+	cp := newCirculation()
+	for id, v := range c.Copies {
+		cp.Copies[id] = v
+	}
+	for id, v := range c.Members {
+		cp.Members[id] = v
+	}
+	for id, v := range c.Loans {
+		cp.Loans[id] = v
+	}
+	for id, v := range c.Holds {
+		cp.Holds[id] = v
+	}
+	cp.NextCopyID, cp.NextMemberID, cp.NextLoanID = c.NextCopyID, c.NextMemberID, c.NextLoanID
+	cp.NextHoldID = c.NextHoldID
+	return cp
+}
+
+// 指定的书借出去的副本数
+func (c Circulation) onLoan(bookID int) int {
+	n := 0
+	for _, cp := range c.Copies {
+		if cp.BookID == bookID && cp.Status == models.OnLoan {
+			n++
+		}
+	}
+	return n
+}
+
+// 会员还没有还的书的数量
+func (c Circulation) activeLoans(memberID int) int {
+	n := 0
+	for _, loan := range c.Loans {
+		if loan.MemberID == memberID && loan.Active() {
+			n++
+		}
+	}
+	return n
+}
+
+// AddCopies 为指定的书添加n个副本，返回新副本的ID；有人预约时新副本直接分配给预约
+func (lib *Library) AddCopies(bookID, n int) ([]int, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	if _, exists := lib.Books[bookID]; !exists {
+		return nil, ErrBookNotFound
+	}
+	now := time.Now()
+	ids := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		id := lib.circulation.NextCopyID
+		lib.release(models.Copy{ID: id, BookID: bookID}, now)
+		lib.circulation.NextCopyID++
+		ids = append(ids, id)
+	}
+	return ids, lib.commit()
+}
+
+// Availability 返回指定的书在架上的副本数和总副本数
+func (lib *Library) Availability(bookID int) (available, total int) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	for _, cp := range lib.circulation.Copies {
+		if cp.BookID != bookID {
+			continue
+		}
+		total++
+		if cp.Status == models.OnShelf {
+			available++
+		}
+	}
+	return available, total
+}
+
+// Checkout 把指定的书借给会员，会员有到书的预约时借走为其保留的副本，
+// 否则从在架的副本中选ID最小的一个
+func (lib *Library) Checkout(memberID, bookID int) (models.Loan, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	// 先单独保存过期的预约，下面的检查失败时不会留下没有保存的修改
+	now := time.Now()
+	lib.expireHolds(now)
+	if err := lib.commit(); err != nil {
+		return models.Loan{}, err
+	}
+
+	member, exists := lib.circulation.Members[memberID]
+	if !exists {
+		return models.Loan{}, ErrMemberNotFound
+	}
+	if _, exists := lib.Books[bookID]; !exists {
+		return models.Loan{}, ErrBookNotFound
+	}
+	if member.Status != models.Active {
+		return models.Loan{}, ErrMemberSuspended
+	}
+	if lib.Policy.MaxFines > 0 && member.Fines > lib.Policy.MaxFines {
+		return models.Loan{}, ErrHasFines
+	}
+	rule := lib.Policy.Rule(member)
+	if lib.circulation.activeLoans(memberID) >= rule.MaxLoans {
+		return models.Loan{}, ErrLoanLimit
+	}
+
+	var chosen models.Copy
+	hold, held := lib.readyHold(memberID, bookID)
+	if held {
+		chosen = lib.circulation.Copies[hold.CopyID]
+	} else {
+		for _, cp := range lib.circulation.Copies {
+			if cp.BookID == bookID && cp.Status == models.OnShelf && (chosen.ID == 0 || cp.ID < chosen.ID) {
+				chosen = cp
+			}
+		}
+	}
+	if chosen.ID == 0 {
+		return models.Loan{}, ErrNoCopyAvailable
+	}
+
+	loan := models.Loan{
+		ID:         lib.circulation.NextLoanID,
+		CopyID:     chosen.ID,
+		BookID:     bookID,
+		MemberID:   memberID,
+		CheckedOut: now,
+		Due:        now.AddDate(0, 0, rule.LoanDays),
+	}
+	lib.circulation.NextLoanID++
+	lib.putLoan(loan)
+
+	if held {
+		hold.Status = models.Fulfilled
+		lib.putHold(hold)
+	}
+
+	chosen.Status = models.OnLoan
+	chosen.LoanID = loan.ID
+	chosen.HoldID = 0
+	lib.putCopy(chosen)
+	return loan, lib.commit()
+}
+
+// Return 归还指定的副本，逾期的罚款记到会员名下；
+// 有人预约这本书时副本保留给排在最前面的会员，可以用GetCopy查看
+func (lib *Library) Return(copyID int) (models.Loan, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	cp, exists := lib.circulation.Copies[copyID]
+	if !exists {
+		return models.Loan{}, ErrCopyNotFound
+	}
+	if cp.Status != models.OnLoan {
+		return models.Loan{}, ErrNotOnLoan
+	}
+
+	now := time.Now()
+	loan := lib.circulation.Loans[cp.LoanID]
+	loan.Returned = now
+	loan.Fine = float64(loan.DaysOverdue(loan.Returned)) * lib.Policy.FinePerDay
+	lib.putLoan(loan)
+
+	if member, exists := lib.circulation.Members[loan.MemberID]; exists && loan.Fine > 0 {
+		member.Fines += loan.Fine
+		lib.putMember(member)
+	}
+
+	cp.LoanID = 0
+	lib.expireHolds(now)
+	lib.release(cp, now)
+	return loan, lib.commit()
+}
+
+// Renew 续借，从现在起按会员等级重新计算借期；
+// 逾期的、达到续借次数的和有人在排队预约的不能续借
+func (lib *Library) Renew(loanID int) (models.Loan, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	loan, exists := lib.circulation.Loans[loanID]
+	if !exists || !loan.Active() {
+		return models.Loan{}, ErrLoanNotFound
+	}
+	now := time.Now()
+	if loan.Overdue(now) {
+		return models.Loan{}, ErrOverdue
+	}
+	if lib.waitingHold(loan.BookID).ID != 0 {
+		return models.Loan{}, ErrHoldPending
+	}
+	rule := lib.Policy.Rule(lib.circulation.Members[loan.MemberID])
+	if loan.Renewals >= rule.MaxRenewals {
+		return models.Loan{}, ErrRenewLimit
+	}
+
+	loan.Renewals++
+	loan.Due = now.AddDate(0, 0, rule.LoanDays)
+	lib.putLoan(loan)
+	return loan, lib.commit()
+}
+
+// PayFine 缴纳罚款，返回剩余的罚款，金额必须大于0
+func (lib *Library) PayFine(memberID int, amount float64) (float64, error) {
+	if !(amount > 0) { // 同时排除NaN
+		return 0, ErrInvalidAmount
+	}
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	member, exists := lib.circulation.Members[memberID]
+	if !exists {
+		return 0, ErrMemberNotFound
+	}
+	member.Fines -= amount
+	if member.Fines < 0 {
+		member.Fines = 0
+	}
+	lib.putMember(member)
+	return member.Fines, lib.commit()
+}
+
+// MemberLoans 列出会员还没有还的借阅记录
+func (lib *Library) MemberLoans(memberID int) []models.Loan {
+	return lib.filterLoans(func(l models.Loan) bool {
+		return l.MemberID == memberID && l.Active()
+	})
+}
+
+// OverdueLoans 列出在now时已经逾期未还的借阅记录
+func (lib *Library) OverdueLoans(now time.Time) []models.Loan {
+	return lib.filterLoans(func(l models.Loan) bool {
+		return l.Overdue(now)
+	})
+}
+
+// 按ID顺序返回符合条件的借阅记录
+func (lib *Library) filterLoans(keep func(models.Loan) bool) []models.Loan {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	loans := make([]models.Loan, 0)
+	for _, loan := range lib.circulation.Loans {
+		if keep(loan) {
+			loans = append(loans, loan)
+		}
+	}
+	sort.Slice(loans, func(i, j int) bool { return loans[i].ID < loans[j].ID })
+	return loans
+}
+
+// 补上旧文件中没有的数据，返回可以直接使用的Circulation
+func (c Circulation) normalized() Circulation {
+	loaded := newCirculation()
+	if c.Copies != nil {
+		loaded.Copies = c.Copies
+	}
+	if c.Members != nil {
+		loaded.Members = c.Members
+	}
+	if c.Loans != nil {
+		loaded.Loans = c.Loans
+	}
+	if c.Holds != nil {
+		loaded.Holds = c.Holds
+	}
+	if c.NextCopyID > 0 {
+		loaded.NextCopyID = c.NextCopyID
+	}
+	if c.NextMemberID > 0 {
+		loaded.NextMemberID = c.NextMemberID
+	}
+	if c.NextLoanID > 0 {
+		loaded.NextLoanID = c.NextLoanID
+	}
+	if c.NextHoldID > 0 {
+		loaded.NextHoldID = c.NextHoldID
+	}
+	return loaded
+}
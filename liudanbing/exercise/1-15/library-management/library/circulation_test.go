@@ -0,0 +1,221 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"math"
+	"testing"
+	"time"
+)
+
+// 一本有copies个副本的书和一个Basic会员，返回书和会员的ID
+func circulationLibrary(t *testing.T, copies int) (lib *Library, bookID, memberID int) {
+	t.Helper()
+
+	lib = NewLibrary()
+	bookID, err := lib.AddBook("Dune", "Herbert", "", models.Fiction, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lib.AddCopies(bookID, copies); err != nil {
+		t.Fatal(err)
+	}
+	memberID = addMember(t, lib, "Ann", models.Basic)
+	return lib, bookID, memberID
+}
+
+func addMember(t *testing.T, lib *Library, name string, tier models.Tier) int {
+	t.Helper()
+
+	id, err := lib.AddMember(models.Member{Name: name, Tier: tier, Status: models.Active})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// 把借阅的应还时间改到d之前，模拟逾期
+func backdate(lib *Library, loanID int, d time.Duration) {
+	loan := lib.circulation.Loans[loanID]
+	loan.Due = time.Now().Add(-d)
+	lib.circulation.Loans[loanID] = loan
+}
+
+func TestCheckoutReturn(t *testing.T) {
+	lib, bookID, memberID := circulationLibrary(t, 2)
+
+	loan, err := lib.Checkout(memberID, bookID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loan.CopyID != 1 || loan.MemberID != memberID || !loan.Active() {
+		t.Errorf("unexpected loan %+v", loan)
+	}
+	if days := loan.Due.Sub(loan.CheckedOut).Hours() / 24; days != 14 {
+		t.Errorf("basic loan is %v days, want 14", days)
+	}
+	if cp, _ := lib.GetCopy(loan.CopyID); cp.Status != models.OnLoan || cp.LoanID != loan.ID {
+		t.Errorf("copy after checkout: %+v", cp)
+	}
+	if available, total := lib.Availability(bookID); available != 1 || total != 2 {
+		t.Errorf("availability %d/%d, want 1/2", available, total)
+	}
+
+	returned, err := lib.Return(loan.CopyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if returned.Active() || returned.Fine != 0 {
+		t.Errorf("returned on time: %+v", returned)
+	}
+	if available, _ := lib.Availability(bookID); available != 2 {
+		t.Errorf("available %d after return, want 2", available)
+	}
+	if _, err := lib.Return(loan.CopyID); !errors.Is(err, ErrNotOnLoan) {
+		t.Errorf("second return: %v", err)
+	}
+	if _, err := lib.Return(99); !errors.Is(err, ErrCopyNotFound) {
+		t.Errorf("unknown copy: %v", err)
+	}
+}
+
+func TestCheckoutErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		setup func(lib *Library, bookID, memberID int) (int, int)
+		want  error
+	}{
+		{"unknown member", func(lib *Library, bookID, memberID int) (int, int) {
+			return 99, bookID
+		}, ErrMemberNotFound},
+		{"unknown book", func(lib *Library, bookID, memberID int) (int, int) {
+			return memberID, 99
+		}, ErrBookNotFound},
+		{"suspended", func(lib *Library, bookID, memberID int) (int, int) {
+			lib.SetMemberStatus(memberID, models.Suspended)
+			return memberID, bookID
+		}, ErrMemberSuspended},
+		{"too many fines", func(lib *Library, bookID, memberID int) (int, int) {
+			m := lib.circulation.Members[memberID]
+			m.Fines = lib.Policy.MaxFines + 1
+			lib.circulation.Members[memberID] = m
+			return memberID, bookID
+		}, ErrHasFines},
+		{"loan limit", func(lib *Library, bookID, memberID int) (int, int) {
+			for i := 0; i < 3; i++ {
+				id, _ := lib.AddBook("Other", "", "", models.Fiction, 1)
+				lib.AddCopies(id, 1)
+				lib.Checkout(memberID, id)
+			}
+			return memberID, bookID
+		}, ErrLoanLimit},
+		{"no copy", func(lib *Library, bookID, memberID int) (int, int) {
+			lib.Checkout(addMember(t, lib, "Bob", models.Basic), bookID)
+			return memberID, bookID
+		}, ErrNoCopyAvailable},
+	} {
+		lib, bookID, memberID := circulationLibrary(t, 1)
+		m, b := tc.setup(lib, bookID, memberID)
+		if _, err := lib.Checkout(m, b); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestRenew(t *testing.T) {
+	lib, bookID, memberID := circulationLibrary(t, 1)
+	loan, _ := lib.Checkout(memberID, bookID)
+
+	renewed, err := lib.Renew(loan.ID)
+	if err != nil || renewed.Renewals != 1 {
+		t.Fatalf("Renew = %+v, %v", renewed, err)
+	}
+	if _, err := lib.Renew(loan.ID); !errors.Is(err, ErrRenewLimit) { // Basic只能续借1次
+		t.Errorf("second renewal: %v", err)
+	}
+	if _, err := lib.Renew(99); !errors.Is(err, ErrLoanNotFound) {
+		t.Errorf("unknown loan: %v", err)
+	}
+
+	// 逾期和有人排队时不能续借
+	lib, bookID, memberID = circulationLibrary(t, 1)
+	loan, _ = lib.Checkout(memberID, bookID)
+	backdate(lib, loan.ID, time.Hour)
+	if _, err := lib.Renew(loan.ID); !errors.Is(err, ErrOverdue) {
+		t.Errorf("overdue renewal: %v", err)
+	}
+
+	lib, bookID, memberID = circulationLibrary(t, 1)
+	loan, _ = lib.Checkout(memberID, bookID)
+	if _, err := lib.PlaceHold(addMember(t, lib, "Bob", models.Basic), bookID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lib.Renew(loan.ID); !errors.Is(err, ErrHoldPending) {
+		t.Errorf("renewal with a hold: %v", err)
+	}
+
+	// 续借次数按会员等级
+	lib, bookID, _ = circulationLibrary(t, 1)
+	loan, _ = lib.Checkout(addMember(t, lib, "Pat", models.Premium), bookID)
+	for i := 1; i <= 3; i++ {
+		if renewed, err := lib.Renew(loan.ID); err != nil || renewed.Renewals != i {
+			t.Fatalf("premium renewal %d: %+v, %v", i, renewed, err)
+		}
+	}
+	if _, err := lib.Renew(loan.ID); !errors.Is(err, ErrRenewLimit) {
+		t.Errorf("fourth premium renewal: %v", err)
+	}
+}
+
+func TestFines(t *testing.T) {
+	for _, tc := range []struct {
+		late time.Duration
+		want float64
+	}{
+		{0, 0},
+		{time.Minute, 0.5}, // 不足一天按一天算
+		{24*time.Hour - time.Minute, 0.5},
+		{24*time.Hour + time.Minute, 1},
+		{10 * 24 * time.Hour, 5.5},
+	} {
+		lib, bookID, memberID := circulationLibrary(t, 1)
+		loan, _ := lib.Checkout(memberID, bookID)
+		if tc.late > 0 {
+			backdate(lib, loan.ID, tc.late)
+		}
+		returned, err := lib.Return(loan.CopyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, _ := lib.GetMember(memberID)
+		if returned.Fine != tc.want || m.Fines != tc.want {
+			t.Errorf("%v late: loan fine %.2f, member fines %.2f, want %.2f", tc.late, returned.Fine, m.Fines, tc.want)
+		}
+	}
+}
+
+func TestPayFine(t *testing.T) {
+	lib, _, memberID := circulationLibrary(t, 0)
+	m := lib.circulation.Members[memberID]
+	m.Fines = 5
+	lib.circulation.Members[memberID] = m
+
+	for _, tc := range []struct {
+		member int
+		amount float64
+		left   float64
+		err    error
+	}{
+		{memberID, 0, 0, ErrInvalidAmount},
+		{memberID, -1, 0, ErrInvalidAmount},
+		{memberID, math.NaN(), 0, ErrInvalidAmount},
+		{99, 1, 0, ErrMemberNotFound},
+		{memberID, 2, 3, nil},
+		{memberID, 10, 0, nil}, // 多付的不会变成负数
+	} {
+		left, err := lib.PayFine(tc.member, tc.amount)
+		if !errors.Is(err, tc.err) || left != tc.left {
+			t.Errorf("PayFine(%d, %v) = %v, %v, want %v, %v", tc.member, tc.amount, left, err, tc.left, tc.err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"sort"
+	"time"
+)
+
+// 预约相关的错误
+var (
+	ErrHoldNotFound  = errors.New("hold not found")
+	ErrCopyAvailable = errors.New("a copy is on the shelf, check it out instead")
+	ErrAlreadyHeld   = errors.New("member already has a hold on this book")
+)
+
+// PlaceHold 预约一本书，只有所有副本都不在架上时才能预约，按预约的先后排队
+func (lib *Library) PlaceHold(memberID, bookID int) (models.Hold, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	// 和Checkout一样，过期的预约先单独保存
+	now := time.Now()
+	lib.expireHolds(now)
+	if err := lib.commit(); err != nil {
+		return models.Hold{}, err
+	}
+
+	member, exists := lib.circulation.Members[memberID]
+	if !exists {
+		return models.Hold{}, ErrMemberNotFound
+	}
+	if member.Status != models.Active {
+		return models.Hold{}, ErrMemberSuspended
+	}
+	if _, exists := lib.Books[bookID]; !exists {
+		return models.Hold{}, ErrBookNotFound
+	}
+	for _, cp := range lib.circulation.Copies {
+		if cp.BookID == bookID && cp.Status == models.OnShelf {
+			return models.Hold{}, ErrCopyAvailable
+		}
+	}
+	for _, h := range lib.circulation.Holds {
+		if h.BookID == bookID && h.MemberID == memberID && h.Active() {
+			return models.Hold{}, ErrAlreadyHeld
+		}
+	}
+
+	hold := models.Hold{
+		ID:       lib.circulation.NextHoldID,
+		BookID:   bookID,
+		MemberID: memberID,
+		Placed:   now,
+		Status:   models.Waiting,
+	}
+	lib.putHold(hold)
+	lib.circulation.NextHoldID++
+	return hold, lib.commit()
+}
+
+// CancelHold 取消预约，已经分配的副本转给下一个预约的会员
+func (lib *Library) CancelHold(holdID int) error {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	hold, exists := lib.circulation.Holds[holdID]
+	if !exists || !hold.Active() {
+		return ErrHoldNotFound
+	}
+	lib.endHold(hold, models.Cancelled, time.Now())
+	return lib.commit()
+}
+
+// ExpireHolds 让超过取书期限的预约过期，副本转给下一个预约的会员，返回过期的预约
+func (lib *Library) ExpireHolds(now time.Time) ([]models.Hold, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	expired := lib.expireHolds(now)
+	return expired, lib.commit()
+}
+
+// BookHolds 按排队顺序列出一本书还有效的预约
+func (lib *Library) BookHolds(bookID int) []models.Hold {
+	return lib.filterHolds(func(h models.Hold) bool {
+		return h.BookID == bookID && h.Active()
+	})
+}
+
+// MemberHolds 列出会员还有效的预约
+func (lib *Library) MemberHolds(memberID int) []models.Hold {
+	return lib.filterHolds(func(h models.Hold) bool {
+		return h.MemberID == memberID && h.Active()
+	})
+}
+
+// GetCopy 查询指定ID的副本
+func (lib *Library) GetCopy(id int) (models.Copy, bool) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	cp, exists := lib.circulation.Copies[id]
+	return cp, exists
+}
+
+// 按ID(也就是排队)顺序返回符合条件的预约，先处理过期的预约
+func (lib *Library) filterHolds(keep func(models.Hold) bool) []models.Hold {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	lib.expireHolds(time.Now())
+	lib.commit() // 保存失败时撤销过期，下次查询时再处理
+	holds := make([]models.Hold, 0)
+	for _, h := range lib.circulation.Holds {
+		if keep(h) {
+			holds = append(holds, h)
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].ID < holds[j].ID })
+	return holds
+}
+
+// 以下函数需要在持有lib.mu的情况下调用
+
+// 一本书排在最前面的等待中的预约，没有时ID为0
+func (lib *Library) waitingHold(bookID int) models.Hold {
+	var first models.Hold
+	for _, h := range lib.circulation.Holds {
+		if h.BookID == bookID && h.Status == models.Waiting && (first.ID == 0 || h.ID < first.ID) {
+			first = h
+		}
+	}
+	return first
+}
+
+// 会员对一本书已经到书、等待取书的预约
+func (lib *Library) readyHold(memberID, bookID int) (models.Hold, bool) {
+	for _, h := range lib.circulation.Holds {
+		if h.BookID == bookID && h.MemberID == memberID && h.Status == models.Ready {
+			return h, true
+		}
+	}
+	return models.Hold{}, false
+}
+
+// 一个副本空出来了：有人排队时保留给第一个人，否则放回架上
+func (lib *Library) release(cp models.Copy, now time.Time) {
+	cp.Status = models.OnShelf
+	cp.HoldID = 0
+	if hold := lib.waitingHold(cp.BookID); hold.ID != 0 {
+		hold.Status = models.Ready
+		hold.CopyID = cp.ID
+		hold.PickupBy = now.AddDate(0, 0, lib.Policy.PickupDays)
+		lib.putHold(hold)
+
+		cp.Status = models.OnHold
+		cp.HoldID = hold.ID
+	}
+	lib.putCopy(cp)
+}
+
+// 结束一个预约，已经分配的副本转给下一个人
+func (lib *Library) endHold(hold models.Hold, status models.HoldStatus, now time.Time) {
+	wasReady := hold.Status == models.Ready
+	hold.Status = status
+	lib.putHold(hold)
+	if wasReady {
+		if cp, exists := lib.circulation.Copies[hold.CopyID]; exists && cp.HoldID == hold.ID {
+			lib.release(cp, now)
+		}
+	}
+}
+
+// 处理超过取书期限的预约，按ID顺序处理，保证副本依次转给后面的人
+func (lib *Library) expireHolds(now time.Time) []models.Hold {
+	expired := make([]models.Hold, 0)
+	for {
+		var next models.Hold
+		for _, h := range lib.circulation.Holds {
+			if h.Status == models.Ready && now.After(h.PickupBy) && (next.ID == 0 || h.ID < next.ID) {
+				next = h
+			}
+		}
+		if next.ID == 0 {
+			return expired
+		}
+		lib.endHold(next, models.Expired, now)
+		next.Status = models.Expired
+		expired = append(expired, next)
+	}
+}
+
+// 取消一本书或者一个会员的全部预约
+func (lib *Library) cancelHolds(match func(models.Hold) bool, now time.Time) {
+	for _, h := range lib.circulation.Holds {
+		if h.Active() && match(h) {
+			lib.endHold(h, models.Cancelled, now)
+		}
+	}
+}
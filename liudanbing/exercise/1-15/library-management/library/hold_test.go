@@ -0,0 +1,157 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 一本书只有一个副本并且已经被借走，bob、carol、dan依次排队预约
+func holdLibrary(t *testing.T) (lib *Library, bookID, copyID int, queue []int) {
+	t.Helper()
+
+	lib, bookID, ann := circulationLibrary(t, 1)
+	loan, err := lib.Checkout(ann, bookID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Bob", "Carol", "Dan"} {
+		id := addMember(t, lib, name, models.Basic)
+		if _, err := lib.PlaceHold(id, bookID); err != nil {
+			t.Fatal(err)
+		}
+		queue = append(queue, id)
+	}
+	return lib, bookID, loan.CopyID, queue
+}
+
+func TestPlaceHoldErrors(t *testing.T) {
+	lib, bookID, memberID := circulationLibrary(t, 1)
+	if _, err := lib.PlaceHold(memberID, bookID); !errors.Is(err, ErrCopyAvailable) {
+		t.Errorf("hold with a copy on the shelf: %v", err)
+	}
+
+	lib.Checkout(addMember(t, lib, "Bob", models.Basic), bookID)
+	suspended := addMember(t, lib, "Eve", models.Basic)
+	lib.SetMemberStatus(suspended, models.Suspended)
+	if _, err := lib.PlaceHold(memberID, bookID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name           string
+		member, bookID int
+		want           error
+	}{
+		{"already held", memberID, bookID, ErrAlreadyHeld},
+		{"suspended", suspended, bookID, ErrMemberSuspended},
+		{"unknown member", 99, bookID, ErrMemberNotFound},
+		{"unknown book", memberID, 99, ErrBookNotFound},
+	} {
+		if _, err := lib.PlaceHold(tc.member, tc.bookID); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+// 还书后副本保留给排在最前面的会员，别人不能借走
+func TestHoldFulfilled(t *testing.T) {
+	lib, bookID, copyID, queue := holdLibrary(t)
+	if _, err := lib.Return(copyID); err != nil {
+		t.Fatal(err)
+	}
+
+	holds := lib.BookHolds(bookID)
+	if len(holds) != 3 || holds[0].MemberID != queue[0] || holds[0].Status != models.Ready || holds[0].CopyID != copyID {
+		t.Fatalf("holds after return: %+v", holds)
+	}
+	if days := time.Until(holds[0].PickupBy).Hours() / 24; days < 2.9 || days > 3 {
+		t.Errorf("pickup window %.2f days, want 3", days)
+	}
+	if cp, _ := lib.GetCopy(copyID); cp.Status != models.OnHold || cp.HoldID != holds[0].ID {
+		t.Errorf("copy after return: %+v", cp)
+	}
+	if _, err := lib.Checkout(queue[1], bookID); !errors.Is(err, ErrNoCopyAvailable) {
+		t.Errorf("checkout by the second member: %v", err)
+	}
+
+	loan, err := lib.Checkout(queue[0], bookID)
+	if err != nil || loan.CopyID != copyID {
+		t.Fatalf("checkout by the first member = %+v, %v", loan, err)
+	}
+	if holds := lib.MemberHolds(queue[0]); len(holds) != 0 {
+		t.Errorf("first member still has holds: %+v", holds)
+	}
+	if holds := lib.BookHolds(bookID); len(holds) != 2 || holds[0].MemberID != queue[1] || holds[0].Status != models.Waiting {
+		t.Errorf("queue after pickup: %+v", holds)
+	}
+}
+
+// 没有按时取书的预约过期，副本按排队顺序转给下一个会员，最后回到书架上
+func TestHoldExpiry(t *testing.T) {
+	lib, bookID, copyID, queue := holdLibrary(t)
+	lib.Return(copyID)
+	start := time.Now()
+
+	for _, tc := range []struct {
+		after   time.Duration // 从还书开始经过的时间
+		expired []int         // 这次过期的会员
+		ready   int           // 之后副本保留给谁，0表示回到书架上
+	}{
+		{2 * 24 * time.Hour, nil, queue[0]},
+		{4 * 24 * time.Hour, []int{queue[0]}, queue[1]},
+		{6 * 24 * time.Hour, nil, queue[1]},
+		{20 * 24 * time.Hour, []int{queue[1]}, queue[2]}, // 取书期限从副本转给会员时开始计算
+		{24 * 24 * time.Hour, []int{queue[2]}, 0},
+	} {
+		expired, err := lib.ExpireHolds(start.Add(tc.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var members []int
+		for _, h := range expired {
+			if h.Status != models.Expired {
+				t.Errorf("%v: expired hold has status %v", tc.after, h.Status)
+			}
+			members = append(members, h.MemberID)
+		}
+		if !reflect.DeepEqual(members, tc.expired) {
+			t.Errorf("%v: expired %v, want %v", tc.after, members, tc.expired)
+		}
+
+		cp, _ := lib.GetCopy(copyID)
+		if tc.ready == 0 {
+			if cp.Status != models.OnShelf || cp.HoldID != 0 {
+				t.Errorf("%v: copy %+v, want on the shelf", tc.after, cp)
+			}
+			continue
+		}
+		hold := lib.circulation.Holds[cp.HoldID]
+		if cp.Status != models.OnHold || hold.MemberID != tc.ready || hold.Status != models.Ready {
+			t.Errorf("%v: copy %+v held by %d, want %d", tc.after, cp, hold.MemberID, tc.ready)
+		}
+	}
+	if available, _ := lib.Availability(bookID); available != 1 {
+		t.Errorf("available %d after every hold expired, want 1", available)
+	}
+}
+
+// 取消已经到书的预约时副本转给下一个会员
+func TestCancelHold(t *testing.T) {
+	lib, bookID, copyID, queue := holdLibrary(t)
+	lib.Return(copyID)
+
+	first := lib.BookHolds(bookID)[0]
+	if err := lib.CancelHold(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.CancelHold(first.ID); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("second cancel: %v", err)
+	}
+	holds := lib.BookHolds(bookID)
+	if len(holds) != 2 || holds[0].MemberID != queue[1] || holds[0].Status != models.Ready || holds[0].CopyID != copyID {
+		t.Errorf("holds after cancelling the first: %+v", holds)
+	}
+}
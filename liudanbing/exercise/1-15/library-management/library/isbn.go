@@ -0,0 +1,56 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+)
+
+var ErrDuplicateISBN = errors.New("a book with this ISBN already exists")
+
+// GetBookByISBN 按ISBN查询书籍，ISBN-10和ISBN-13都可以
+func (lib *Library) GetBookByISBN(isbn string) (models.Book, bool) {
+	isbn, err := models.NormalizeISBN(isbn)
+	if err != nil {
+		return models.Book{}, false
+	}
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	id, exists := lib.isbnIndex[isbn]
+	if !exists {
+		return models.Book{}, false
+	}
+	return lib.Books[id], true
+}
+
+// 以下函数需要在持有lib.mu的情况下调用
+
+// 检查isbn是否合法，是否已经被id以外的书使用，返回统一格式的ISBN-13，空字符串表示没有ISBN
+func (lib *Library) checkISBN(isbn string, id int) (string, error) {
+	if isbn == "" {
+		return "", nil
+	}
+	isbn, err := models.NormalizeISBN(isbn)
+	if err != nil {
+		return "", err
+	}
+	if other, exists := lib.isbnIndex[isbn]; exists && other != id {
+		return "", ErrDuplicateISBN
+	}
+	return isbn, nil
+}
+
+func (lib *Library) indexISBN(isbn string, id int) {
+	if isbn != "" {
+		lib.isbnIndex[isbn] = id
+	}
+}
+
+// 加载数据之后重建索引，旧文件中的书没有ISBN
+func (lib *Library) rebuildISBNIndex() {
+	lib.isbnIndex = make(map[string]int, len(lib.Books))
+	for id, book := range lib.Books {
+		lib.indexISBN(book.ISBN, id)
+	}
+}
@@ -0,0 +1,80 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"testing"
+)
+
+// ISBN-10和ISBN-13是同一本书，重复时不能添加
+func TestDuplicateISBN(t *testing.T) {
+	lib := NewLibrary()
+	id, err := lib.AddBook("Go", "Donovan", "0-13-419044-0", models.Computer, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book, _ := lib.GetBook(id); book.ISBN != "9780134190440" {
+		t.Errorf("stored ISBN %q, want the normalized ISBN-13", book.ISBN)
+	}
+	other, _ := lib.AddBook("Dune", "Herbert", "", models.Fiction, 10)
+
+	for _, tc := range []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"same ISBN-13", func() error {
+			_, err := lib.AddBook("Copy", "", "9780134190440", models.Computer, 1)
+			return err
+		}, ErrDuplicateISBN},
+		{"ISBN-10 of the same book", func() error {
+			_, err := lib.AddBook("Copy", "", "0134190440", models.Computer, 1)
+			return err
+		}, ErrDuplicateISBN},
+		{"invalid", func() error {
+			_, err := lib.AddBook("Bad", "", "0134190441", models.Computer, 1)
+			return err
+		}, models.ErrInvalidISBN},
+		{"duplicate in a batch", func() error {
+			_, err := lib.AddBooks([]models.Book{{Title: "A", ISBN: "0306406152"}, {Title: "B", ISBN: "9780306406157"}})
+			return err
+		}, ErrDuplicateISBN},
+		{"update to another book's ISBN", func() error {
+			return lib.UpdateBook(other, "Dune", "Herbert", "9780134190440", models.Fiction, 10)
+		}, ErrDuplicateISBN},
+		{"update keeping its own ISBN", func() error {
+			return lib.UpdateBook(id, "Go 2nd", "Donovan", "0134190440", models.Computer, 40)
+		}, nil},
+	} {
+		if err := tc.err(); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if len(lib.ListBooks()) != 2 {
+		t.Errorf("%d books, want 2", len(lib.ListBooks()))
+	}
+}
+
+func TestGetBookByISBN(t *testing.T) {
+	lib := NewLibrary()
+	id, _ := lib.AddBook("Go", "Donovan", "9780134190440", models.Computer, 40)
+
+	for _, isbn := range []string{"9780134190440", "978-0-13-419044-0", "0134190440"} {
+		if book, ok := lib.GetBookByISBN(isbn); !ok || book.ID != id {
+			t.Errorf("GetBookByISBN(%q) = %+v, %v", isbn, book, ok)
+		}
+	}
+	if _, ok := lib.GetBookByISBN("bad"); ok {
+		t.Error("found a book by an invalid ISBN")
+	}
+
+	// 修改和删除之后旧的ISBN可以再使用
+	lib.UpdateBook(id, "Go", "Donovan", "0306406152", models.Computer, 40)
+	if _, ok := lib.GetBookByISBN("9780134190440"); ok {
+		t.Error("old ISBN still indexed after update")
+	}
+	lib.DeleteBook(id)
+	if _, err := lib.AddBook("Other", "", "0306406152", models.Science, 1); err != nil {
+		t.Errorf("reusing a deleted book's ISBN: %v", err)
+	}
+}
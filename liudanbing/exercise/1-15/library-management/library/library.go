@@ -1,14 +1,22 @@
 package library
 
 import (
+	"fmt"
 	"library-management/models"
 	"sync"
+	"time"
 )
 
 // Library 表示图书馆
 type Library struct {
 	Books map[int]models.Book
 	NextID int	
+	isbnIndex map[string]int // ISBN -> 书的ID
+	circulation Circulation // 副本、会员、借阅和预约记录
+	Policy Policy // 借阅规则
+	store Store // 为nil时只保存在内存中
+	pending Change // 还没有保存到store的修改
+	undo undoLog // pending中的修改之前的数据，保存失败时用来撤销
 	mu sync.Mutex // 互斥锁
 }
 
@@ -17,56 +25,140 @@ func NewLibrary() *Library {
 	return &Library{
 		Books: make(map[int]models.Book),
 		NextID: 1,
+		isbnIndex: make(map[string]int),
+		circulation: newCirculation(),
+		Policy: DefaultPolicy(),
 	}
 }
 
 // AddBook 向图书馆中添加一本书，返回书的ID
-func (lib *Library) AddBook(title, author string, category models.Category, price float64) int {
+// isbn可以为空，也可以是ISBN-10或ISBN-13，不合法或者已经存在时返回错误
+func (lib *Library) AddBook(title, author, isbn string, category models.Category, price float64) (int, error) {
 	lib.mu.Lock()
 	defer lib.mu.Unlock()
 
+	id, err := lib.addBook(title, author, isbn, category, price)
+	if err != nil {
+		return 0, err
+	}
+	if err := lib.commit(); err != nil { // 保存失败时书已经被撤销
+		return 0, err
+	}
+	return id, nil
+}
+
+// AddBooks 在一个事务中添加多本书，只要有一本不能添加就一本都不添加，返回新书的ID
+func (lib *Library) AddBooks(books []models.Book) ([]int, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	// 先检查全部的书，包括这一批书之间的ISBN重复
+	seen := make(map[string]bool)
+	for i, book := range books {
+		isbn, err := lib.checkISBN(book.ISBN, 0)
+		if err == nil && isbn != "" && seen[isbn] {
+			err = ErrDuplicateISBN
+		}
+		if err != nil {
+			return nil, fmt.Errorf("book %d (%s): %w", i+1, book.Title, err)
+		}
+		seen[isbn] = true
+	}
+
+	ids := make([]int, 0, len(books))
+	for _, book := range books {
+		id, _ := lib.addBook(book.Title, book.Author, book.ISBN, book.Category, book.Price)
+		ids = append(ids, id)
+	}
+	if err := lib.commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// 需要在持有lib.mu的情况下调用
+func (lib *Library) addBook(title, author, isbn string, category models.Category, price float64) (int, error) {
+	isbn, err := lib.checkISBN(isbn, 0)
+	if err != nil {
+		return 0, err
+	}
+
 	id := lib.NextID
-	lib.Books[id] = models.Book{
+	lib.putBook(models.Book{
 		ID: id,
 		Title: title,
 		Author: author,
 		Category: category,
 		Price: price,
-	}
+		ISBN: isbn,
+	})
+	lib.indexISBN(isbn, id)
 	lib.NextID++
-	return id
+	return id, nil
+}
+
+// DeleteBook 删除指定ID的书和它的副本，取消它的预约，还有副本借出时不能删除
+func (lib *Library) DeleteBook(id int) error {
+	return lib.DeleteBookIf(id, nil)
 }
 
-// DeleteBook 删除指定ID的书，返回是否删除成功
-func (lib *Library) DeleteBook(id int) bool {
+// DeleteBookIf 和DeleteBook一样，但是只有当前的书满足match时才删除，否则返回ErrBookChanged，
+// 用于乐观并发控制；match为nil时不检查
+func (lib *Library) DeleteBookIf(id int, match func(models.Book) bool) error {
 	lib.mu.Lock()
 	defer lib.mu.Unlock()
 
-	if _, exists := lib.Books[id]; exists {
-		delete(lib.Books, id)
-		return true
+	book, exists := lib.Books[id]
+	if !exists {
+		return ErrBookNotFound
+	}
+	if match != nil && !match(book) {
+		return ErrBookChanged
 	}
+	if lib.circulation.onLoan(id) > 0 {
+		return ErrBookOnLoan
+	}
+	lib.cancelHolds(func(h models.Hold) bool { return h.BookID == id }, time.Now())
+	lib.removeCopies(id)
+	delete(lib.isbnIndex, lib.Books[id].ISBN)
+	lib.removeBook(id)
+	return lib.commit()
+}
 
-	return false
+// UpdateBook 更新指定ID的书，ISBN不合法或者和别的书重复时返回错误
+func (lib *Library) UpdateBook(id int, title, author, isbn string, category models.Category, price float64) error {
+	return lib.UpdateBookIf(id, nil, title, author, isbn, category, price)
 }
 
-// updateBook 更新指定ID的书，返回是否成功
-func (lib *Library) UpdateBook(id int, title, author string, category models.Category, price float64) bool {
+// UpdateBookIf 和UpdateBook一样，但是只有当前的书满足match时才更新，否则返回ErrBookChanged，
+// 用于乐观并发控制；match为nil时不检查
+func (lib *Library) UpdateBookIf(id int, match func(models.Book) bool, title, author, isbn string, category models.Category, price float64) error {
 	lib.mu.Lock()
 	defer lib.mu.Unlock()
 
-	if _, exists := lib.Books[id]; exists {
-		lib.Books[id] = models.Book{
-			ID: id,
-			Title: title,
-			Author: author,
-			Category: category,
-			Price: price,
-		}
-		return true
+	old, exists := lib.Books[id]
+	if !exists {
+		return ErrBookNotFound
+	}
+	if match != nil && !match(old) {
+		return ErrBookChanged
+	}
+	isbn, err := lib.checkISBN(isbn, id)
+	if err != nil {
+		return err
 	}
 
-	return false
+	lib.putBook(models.Book{
+		ID: id,
+		Title: title,
+		Author: author,
+		Category: category,
+		Price: price,
+		ISBN: isbn,
+	})
+	delete(lib.isbnIndex, old.ISBN)
+	lib.indexISBN(isbn, id)
+	return lib.commit()
 }
 
 // 查询指定ID的书籍，返回书籍和是否存在
@@ -96,8 +188,8 @@ func (lib *Library) SetBooks(books map[int]models.Book, nextID int) {
 	lib.mu.Lock()
 	defer lib.mu.Unlock()
 
-	lib.Books = books
-	lib.NextID = nextID
+	lib.reset(Snapshot{Books: books, NextID: nextID, Circulation: lib.circulation})
+	lib.commit() // 保存失败时数据保持不变
 }
 
 // 获取Library的Books和NextID，用于保存数据
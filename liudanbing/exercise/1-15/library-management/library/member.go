@@ -0,0 +1,116 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 会员相关的错误
+var (
+	ErrMemberNotFound  = errors.New("member not found")
+	ErrMemberSuspended = errors.New("member is suspended")
+	ErrMemberHasLoans  = errors.New("member still has books on loan")
+	ErrInvalidMember   = errors.New("invalid member: name is required and tier must be known")
+)
+
+// 检查会员信息是否合法
+func validateMember(m models.Member) error {
+	if strings.TrimSpace(m.Name) == "" || !m.Tier.Valid() || m.MaxLoans < 0 {
+		return ErrInvalidMember
+	}
+	if m.Status != models.Active && m.Status != models.Suspended {
+		return ErrInvalidMember
+	}
+	return nil
+}
+
+// AddMember 添加一个会员，忽略m.ID、m.Joined和m.Fines，返回会员的ID
+func (lib *Library) AddMember(m models.Member) (int, error) {
+	if err := validateMember(m); err != nil {
+		return 0, err
+	}
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	m.ID = lib.circulation.NextMemberID
+	m.Joined = time.Now()
+	m.Fines = 0
+	lib.putMember(m)
+	lib.circulation.NextMemberID++
+	return m.ID, lib.commit()
+}
+
+// UpdateMember 按m.ID更新会员的信息，入会时间和罚款不会被修改
+func (lib *Library) UpdateMember(m models.Member) error {
+	if err := validateMember(m); err != nil {
+		return err
+	}
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	old, exists := lib.circulation.Members[m.ID]
+	if !exists {
+		return ErrMemberNotFound
+	}
+	m.Joined = old.Joined
+	m.Fines = old.Fines
+	lib.putMember(m)
+	return lib.commit()
+}
+
+// SetMemberStatus 启用或停用会员
+func (lib *Library) SetMemberStatus(id int, status models.MemberStatus) error {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	m, exists := lib.circulation.Members[id]
+	if !exists {
+		return ErrMemberNotFound
+	}
+	m.Status = status
+	lib.putMember(m)
+	return lib.commit()
+}
+
+// DeleteMember 删除会员并取消该会员的预约，还有书没还时不能删除
+func (lib *Library) DeleteMember(id int) error {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	if _, exists := lib.circulation.Members[id]; !exists {
+		return ErrMemberNotFound
+	}
+	if lib.circulation.activeLoans(id) > 0 {
+		return ErrMemberHasLoans
+	}
+	lib.cancelHolds(func(h models.Hold) bool { return h.MemberID == id }, time.Now())
+	lib.removeMember(id)
+	return lib.commit()
+}
+
+// GetMember 查询指定ID的会员
+func (lib *Library) GetMember(id int) (models.Member, bool) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	m, exists := lib.circulation.Members[id]
+	return m, exists
+}
+
+// ListMembers 按ID顺序列出所有会员
+func (lib *Library) ListMembers() []models.Member {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	members := make([]models.Member, 0, len(lib.circulation.Members))
+	for _, m := range lib.circulation.Members {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members
+}
@@ -0,0 +1,97 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"testing"
+)
+
+func TestMemberValidation(t *testing.T) {
+	lib := NewLibrary()
+	for _, tc := range []struct {
+		name string
+		m    models.Member
+		ok   bool
+	}{
+		{"valid", models.Member{Name: "Ann", Tier: models.Standard}, true},
+		{"blank name", models.Member{Name: "  ", Tier: models.Basic}, false},
+		{"unknown tier", models.Member{Name: "Ann", Tier: 7}, false},
+		{"negative max loans", models.Member{Name: "Ann", MaxLoans: -1}, false},
+		{"unknown status", models.Member{Name: "Ann", Status: 5}, false},
+	} {
+		_, err := lib.AddMember(tc.m)
+		if tc.ok != (err == nil) || (err != nil && !errors.Is(err, ErrInvalidMember)) {
+			t.Errorf("%s: AddMember = %v", tc.name, err)
+		}
+	}
+}
+
+func TestMemberCRUD(t *testing.T) {
+	lib := NewLibrary()
+	id := addMember(t, lib, "Ann", models.Basic)
+
+	// 入会时间和罚款不能通过UpdateMember修改
+	m, _ := lib.GetMember(id)
+	joined := m.Joined
+	if err := lib.UpdateMember(models.Member{ID: id, Name: "Ann Lee", Tier: models.Premium, Fines: 100}); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = lib.GetMember(id)
+	if m.Name != "Ann Lee" || m.Tier != models.Premium || m.Fines != 0 || !m.Joined.Equal(joined) {
+		t.Errorf("after update: %+v", m)
+	}
+	if err := lib.UpdateMember(models.Member{ID: 99, Name: "X"}); !errors.Is(err, ErrMemberNotFound) {
+		t.Errorf("update unknown member: %v", err)
+	}
+
+	// 单独设置的借阅上限优先于等级的规则
+	if rule := lib.Policy.Rule(models.Member{Tier: models.Basic, MaxLoans: 7}); rule.MaxLoans != 7 || rule.LoanDays != 14 {
+		t.Errorf("rule with MaxLoans override: %+v", rule)
+	}
+
+	if err := lib.SetMemberStatus(id, models.Suspended); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := lib.GetMember(id); m.Status != models.Suspended {
+		t.Errorf("status %v, want suspended", m.Status)
+	}
+
+	addMember(t, lib, "Bob", models.Basic)
+	if members := lib.ListMembers(); len(members) != 2 || members[0].ID != id {
+		t.Errorf("ListMembers = %+v", members)
+	}
+
+	if err := lib.DeleteMember(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lib.GetMember(id); ok {
+		t.Error("member still exists after delete")
+	}
+	if err := lib.DeleteMember(id); !errors.Is(err, ErrMemberNotFound) {
+		t.Errorf("second delete: %v", err)
+	}
+}
+
+// 有书没还的会员不能删除，删除会员时取消其预约
+func TestDeleteMemberWithLoansAndHolds(t *testing.T) {
+	lib, bookID, memberID := circulationLibrary(t, 1)
+	lib.Checkout(memberID, bookID)
+	if err := lib.DeleteMember(memberID); !errors.Is(err, ErrMemberHasLoans) {
+		t.Errorf("delete with a loan: %v", err)
+	}
+
+	bob := addMember(t, lib, "Bob", models.Basic)
+	hold, err := lib.PlaceHold(bob, bookID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.DeleteMember(bob); err != nil {
+		t.Fatal(err)
+	}
+	if holds := lib.BookHolds(bookID); len(holds) != 0 {
+		t.Errorf("holds after deleting the member: %+v", holds)
+	}
+	if h := lib.circulation.Holds[hold.ID]; h.Status != models.Cancelled {
+		t.Errorf("hold status %v, want cancelled", h.Status)
+	}
+}
@@ -0,0 +1,164 @@
+package library
+
+import (
+	"fmt"
+	"library-management/models"
+	"sort"
+	"strings"
+)
+
+// SortField 表示搜索结果的排序字段
+type SortField int
+
+const (
+	SortByID SortField = iota
+	SortByTitle
+	SortByAuthor
+	SortByPrice
+)
+
+// SortFieldNames 存储排序字段名称，便于显示
+var SortFieldNames = []string{
+	"ID",
+	"Title",
+	"Author",
+	"Price",
+}
+
+func (s SortField) String() string {
+	if int(s) >= 0 && int(s) < len(SortFieldNames) {
+		return SortFieldNames[s]
+	}
+	return "Unknown"
+}
+
+// ParseSortField 按名称(不区分大小写)解析排序字段
+func ParseSortField(s string) (SortField, error) {
+	for i, name := range SortFieldNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return SortField(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sort field %q, use one of %s", s, strings.ToLower(strings.Join(SortFieldNames, ", ")))
+}
+
+// Query 是搜索条件，零值表示列出所有书籍
+type Query struct {
+	Text     string           // 在书名和作者中查找，不区分大小写；是一个ISBN时也按ISBN查找
+	Tokens   bool             // 为true时把Text拆成单词，每个单词都要出现(顺序不限)，否则整体作为子串匹配
+	Category *models.Category // 为nil时不限类别
+	MinPrice float64          // 价格下限，0表示不限
+	MaxPrice float64          // 价格上限，0表示不限
+
+	SortBy SortField
+	Desc   bool // 是否倒序
+
+	Page     int // 页码，从1开始，0当作1
+	PageSize int // 每页的数量，0表示不分页
+}
+
+// SearchResult 是一页搜索结果
+type SearchResult struct {
+	Books []models.Book
+	Total int // 符合条件的书籍总数(所有页)
+	Page  int
+	Pages int // 总页数
+}
+
+// Search 按照q查找书籍，返回排序和分页之后的结果
+func (lib *Library) Search(q Query) SearchResult {
+	lib.mu.Lock()
+	matched := make([]models.Book, 0)
+	for _, book := range lib.Books {
+		if q.Match(book) {
+			matched = append(matched, book)
+		}
+	}
+	lib.mu.Unlock()
+
+	sortBooks(matched, q.SortBy, q.Desc)
+
+	result := SearchResult{Total: len(matched), Page: 1, Pages: 1}
+	if q.PageSize <= 0 {
+		result.Books = matched
+		return result
+	}
+
+	result.Pages = (len(matched) + q.PageSize - 1) / q.PageSize
+	if result.Pages == 0 {
+		result.Pages = 1
+	}
+	if q.Page > 1 {
+		result.Page = q.Page
+	}
+	start := (result.Page - 1) * q.PageSize
+	if start >= len(matched) {
+		result.Books = []models.Book{}
+		return result
+	}
+	end := start + q.PageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	result.Books = matched[start:end]
+	return result
+}
+
+// Match 判断一本书是否符合搜索条件(不考虑排序和分页)
+func (q Query) Match(book models.Book) bool {
+	if q.Category != nil && book.Category != *q.Category {
+		return false
+	}
+	if q.MinPrice > 0 && book.Price < q.MinPrice {
+		return false
+	}
+	if q.MaxPrice > 0 && book.Price > q.MaxPrice {
+		return false
+	}
+
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+	if text == "" {
+		return true
+	}
+	if isbn, err := models.NormalizeISBN(text); err == nil && isbn == book.ISBN {
+		return true
+	}
+	if !q.Tokens {
+		return strings.Contains(strings.ToLower(book.Title), text) ||
+			strings.Contains(strings.ToLower(book.Author), text)
+	}
+	haystack := strings.ToLower(book.Title + "\n" + book.Author)
+	for _, token := range strings.Fields(text) {
+		if !strings.Contains(haystack, token) {
+			return false
+		}
+	}
+	return true
+}
+
+// 按字段排序，字段相同时按ID排序，保证结果稳定
+func sortBooks(books []models.Book, by SortField, desc bool) {
+	less := func(a, b models.Book) bool {
+		switch by {
+		case SortByTitle:
+			if x, y := strings.ToLower(a.Title), strings.ToLower(b.Title); x != y {
+				return x < y
+			}
+		case SortByAuthor:
+			if x, y := strings.ToLower(a.Author), strings.ToLower(b.Author); x != y {
+				return x < y
+			}
+		case SortByPrice:
+			if a.Price != b.Price {
+				return a.Price < b.Price
+			}
+		}
+		return a.ID < b.ID
+	}
+	sort.Slice(books, func(i, j int) bool {
+		if desc {
+			return less(books[j], books[i])
+		}
+		return less(books[i], books[j])
+	})
+}
@@ -0,0 +1,117 @@
+package library
+
+import (
+	"library-management/models"
+	"reflect"
+	"testing"
+)
+
+// 书的ID，用于比较搜索结果
+func bookIDs(books []models.Book) []int {
+	ids := make([]int, 0, len(books))
+	for _, b := range books {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func searchLibrary(t *testing.T) *Library {
+	t.Helper()
+
+	lib := NewLibrary()
+	for _, b := range []models.Book{
+		{Title: "The Go Programming Language", Author: "Donovan", ISBN: "9780134190440", Category: models.Computer, Price: 40},
+		{Title: "go in action", Author: "Kennedy", Category: models.Computer, Price: 30},
+		{Title: "A Brief History of Time", Author: "Hawking", Category: models.Science, Price: 15},
+		{Title: "Steve Jobs", Author: "Isaacson", Category: models.Biography, Price: 30},
+		{Title: "Dune", Author: "Herbert", Category: models.Fiction, Price: 10},
+	} {
+		if _, err := lib.AddBook(b.Title, b.Author, b.ISBN, b.Category, b.Price); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return lib
+}
+
+func TestSearch(t *testing.T) {
+	lib := searchLibrary(t)
+	computer, fiction := models.Computer, models.Fiction
+
+	for _, tc := range []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		{"all", Query{}, []int{1, 2, 3, 4, 5}},
+		{"title case insensitive", Query{Text: "GO"}, []int{1, 2}},
+		{"author", Query{Text: "hawking"}, []int{3}},
+		{"phrase", Query{Text: "language go"}, []int{}},
+		{"tokens in any order", Query{Text: "language go", Tokens: true}, []int{1}},
+		{"isbn-10 of the same book", Query{Text: "0-13-419044-0"}, []int{1}},
+		{"category", Query{Category: &computer}, []int{1, 2}},
+		{"category and text", Query{Category: &fiction, Text: "go"}, []int{}},
+		{"price range", Query{MinPrice: 15, MaxPrice: 30}, []int{2, 3, 4}},
+		{"title", Query{SortBy: SortByTitle}, []int{3, 5, 2, 4, 1}},
+		{"author desc", Query{SortBy: SortByAuthor, Desc: true}, []int{2, 4, 5, 3, 1}},
+		{"price ties by id", Query{SortBy: SortByPrice}, []int{5, 3, 2, 4, 1}},
+		{"price desc", Query{SortBy: SortByPrice, Desc: true}, []int{1, 4, 2, 3, 5}},
+	} {
+		got := lib.Search(tc.q)
+		if ids := bookIDs(got.Books); !reflect.DeepEqual(ids, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, ids, tc.want)
+		}
+		if got.Total != len(tc.want) {
+			t.Errorf("%s: total %d, want %d", tc.name, got.Total, len(tc.want))
+		}
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	lib := searchLibrary(t)
+
+	for _, tc := range []struct {
+		page, size int
+		want       []int
+		gotPage    int
+		pages      int
+	}{
+		{0, 0, []int{1, 2, 3, 4, 5}, 1, 1}, // 不分页
+		{0, 2, []int{1, 2}, 1, 3},          // 0当作第1页
+		{2, 2, []int{3, 4}, 2, 3},
+		{3, 2, []int{5}, 3, 3}, // 最后一页不满
+		{4, 2, []int{}, 4, 3},  // 超出范围时为空
+		{1, 10, []int{1, 2, 3, 4, 5}, 1, 1},
+	} {
+		got := lib.Search(Query{Page: tc.page, PageSize: tc.size})
+		if ids := bookIDs(got.Books); !reflect.DeepEqual(ids, tc.want) || got.Page != tc.gotPage || got.Pages != tc.pages || got.Total != 5 {
+			t.Errorf("page %d size %d: got %v page %d/%d total %d, want %v page %d/%d total 5",
+				tc.page, tc.size, ids, got.Page, got.Pages, got.Total, tc.want, tc.gotPage, tc.pages)
+		}
+	}
+
+	// 没有结果时也有1页
+	got := lib.Search(Query{Text: "nothing", PageSize: 2})
+	if got.Pages != 1 || got.Total != 0 || len(got.Books) != 0 {
+		t.Errorf("empty result: got %+v", got)
+	}
+}
+
+func TestParseSortField(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want SortField
+		ok   bool
+	}{
+		{"id", SortByID, true},
+		{"Title", SortByTitle, true},
+		{" AUTHOR ", SortByAuthor, true},
+		{"price", SortByPrice, true},
+		{"isbn", 0, false},
+		{"", 0, false},
+	} {
+		got, err := ParseSortField(tc.in)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseSortField(%q) = %v, %v", tc.in, got, err)
+		}
+	}
+}
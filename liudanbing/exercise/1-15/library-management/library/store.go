@@ -0,0 +1,360 @@
+package library
+
+import (
+	"library-management/models"
+)
+
+// Store 持久化图书馆的数据。Library 每次修改之后马上调用 Apply 保存这次的修改，
+// 实现见 store 包(JSON文件、WAL加快照、SQLite)
+type Store interface {
+	Load() (Snapshot, error) // 读取全部数据，没有数据时返回空的Snapshot
+	Apply(ch Change) error   // 保存一次修改
+	Close() error
+}
+
+// Snapshot 是图书馆的全部数据，也是JSON文件的格式
+type Snapshot struct {
+	Books       map[int]models.Book `json:"books"`
+	NextID      int                 `json:"next_id"`
+	Circulation                     // 副本、会员、借阅和预约记录
+}
+
+// NewSnapshot 返回一个空的Snapshot
+func NewSnapshot() Snapshot {
+	return Snapshot{Books: make(map[int]models.Book), NextID: 1, Circulation: newCirculation()}
+}
+
+// 补上旧文件中没有的数据
+func (s Snapshot) normalized() Snapshot {
+	if s.Books == nil {
+		s.Books = make(map[int]models.Book)
+	}
+	if s.NextID <= 0 {
+		s.NextID = 1
+	}
+	s.Circulation = s.Circulation.normalized()
+	return s
+}
+
+// Clone 复制一份，修改副本不会影响原来的Snapshot
+func (s Snapshot) Clone() Snapshot {
+	cp := Snapshot{Books: make(map[int]models.Book, len(s.Books)), NextID: s.NextID}
+	for id, book := range s.Books {
+		cp.Books[id] = book
+	}
+	cp.Circulation = s.Circulation.normalized().clone()
+	return cp.normalized()
+}
+
+// Counters 是各种记录的下一个ID
+type Counters struct {
+	Book   int `json:"book"`
+	Copy   int `json:"copy"`
+	Member int `json:"member"`
+	Loan   int `json:"loan"`
+	Hold   int `json:"hold"`
+}
+
+// Change 是一次修改：新增或者修改的记录和删除的记录的ID。
+// ID不会重复使用，所以应用时先写入再删除就能得到正确的结果
+type Change struct {
+	Reset *Snapshot `json:"reset,omitempty"` // 不为nil时先用它替换全部数据，例如加载文件
+
+	Books   map[int]models.Book   `json:"books,omitempty"`
+	Copies  map[int]models.Copy   `json:"copies,omitempty"`
+	Members map[int]models.Member `json:"members,omitempty"`
+	Loans   map[int]models.Loan   `json:"loans,omitempty"`
+	Holds   map[int]models.Hold   `json:"holds,omitempty"`
+
+	DeletedBooks   []int `json:"deleted_books,omitempty"`
+	DeletedCopies  []int `json:"deleted_copies,omitempty"`
+	DeletedMembers []int `json:"deleted_members,omitempty"`
+
+	Next Counters `json:"next"`
+}
+
+// Empty 表示没有任何修改
+func (ch Change) Empty() bool {
+	return ch.Reset == nil && len(ch.Books) == 0 && len(ch.Copies) == 0 && len(ch.Members) == 0 &&
+		len(ch.Loans) == 0 && len(ch.Holds) == 0 &&
+		len(ch.DeletedBooks) == 0 && len(ch.DeletedCopies) == 0 && len(ch.DeletedMembers) == 0
+}
+
+// Apply 把一次修改应用到Snapshot上
+func (s *Snapshot) Apply(ch Change) {
+	if ch.Reset != nil {
+		*s = ch.Reset.Clone()
+	}
+	*s = s.normalized()
+
+	for id, v := range ch.Books {
+		s.Books[id] = v
+	}
+	for id, v := range ch.Copies {
+		s.Copies[id] = v
+	}
+	for id, v := range ch.Members {
+		s.Members[id] = v
+	}
+	for id, v := range ch.Loans {
+		s.Loans[id] = v
+	}
+	for id, v := range ch.Holds {
+		s.Holds[id] = v
+	}
+	for _, id := range ch.DeletedBooks {
+		delete(s.Books, id)
+	}
+	for _, id := range ch.DeletedCopies {
+		delete(s.Copies, id)
+	}
+	for _, id := range ch.DeletedMembers {
+		delete(s.Members, id)
+	}
+
+	if ch.Next.Book > 0 {
+		s.NextID = ch.Next.Book
+		s.NextCopyID = ch.Next.Copy
+		s.NextMemberID = ch.Next.Member
+		s.NextLoanID = ch.Next.Loan
+		s.NextHoldID = ch.Next.Hold
+	}
+}
+
+// Open 从store中加载数据，之后每次修改都会保存到store
+func Open(store Store) (*Library, error) {
+	snap, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	lib := NewLibrary()
+	lib.restore(snap)
+	lib.store = store
+	lib.saved()
+	return lib, nil
+}
+
+// Close 关闭store，没有store时什么都不做
+func (lib *Library) Close() error {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	if lib.store == nil {
+		return nil
+	}
+	err := lib.commit()
+	if cerr := lib.store.Close(); err == nil {
+		err = cerr
+	}
+	lib.store = nil
+	return err
+}
+
+// Snapshot 返回图书馆全部数据的副本，用于保存数据
+func (lib *Library) Snapshot() Snapshot {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	return lib.snapshot()
+}
+
+// Restore 用snap替换图书馆的全部数据，用于加载数据
+func (lib *Library) Restore(snap Snapshot) error {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+
+	lib.reset(snap)
+	return lib.commit()
+}
+
+// 以下函数需要在持有lib.mu的情况下调用
+
+func (lib *Library) snapshot() Snapshot {
+	snap := Snapshot{Books: lib.Books, NextID: lib.NextID, Circulation: lib.circulation}
+	return snap.Clone()
+}
+
+func (lib *Library) restore(snap Snapshot) {
+	snap = snap.Clone()
+	lib.Books = snap.Books
+	lib.NextID = snap.NextID
+	lib.circulation = snap.Circulation
+	lib.rebuildISBNIndex()
+}
+
+func (lib *Library) counters() Counters {
+	return Counters{
+		Book:   lib.NextID,
+		Copy:   lib.circulation.NextCopyID,
+		Member: lib.circulation.NextMemberID,
+		Loan:   lib.circulation.NextLoanID,
+		Hold:   lib.circulation.NextHoldID,
+	}
+}
+
+// 把还没有保存的修改交给store，失败时撤销内存中的这些修改，
+// 这样内存中的数据总是和store中的一致
+func (lib *Library) commit() error {
+	if lib.store == nil || lib.pending.Empty() {
+		lib.saved()
+		return nil
+	}
+	lib.pending.Next = lib.counters()
+	if err := lib.store.Apply(lib.pending); err != nil {
+		lib.rollback()
+		return err
+	}
+	lib.saved()
+	return nil
+}
+
+// 当前的数据已经保存，清空pending和undo
+func (lib *Library) saved() {
+	lib.pending = Change{}
+	lib.undo = undoLog{next: lib.counters()}
+}
+
+// 撤销上一次保存之后的全部修改
+func (lib *Library) rollback() {
+	snap := lib.snapshot()
+	lib.undo.revert(&snap)
+	lib.restore(snap)
+	lib.saved()
+}
+
+// 用snap替换全部数据并记录到pending中
+func (lib *Library) reset(snap Snapshot) {
+	if lib.undo.reset == nil {
+		base := lib.snapshot()
+		lib.undo.revert(&base)
+		lib.undo.reset = &base
+	}
+	lib.restore(snap)
+	reset := lib.snapshot()
+	lib.pending = Change{Reset: &reset}
+}
+
+// undoLog 记录上一次保存之后被修改的记录原来的值，保存失败时用来撤销修改
+type undoLog struct {
+	reset   *Snapshot            // 不为nil时是替换全部数据之前的数据
+	books   map[int]*models.Book // nil表示原来没有这条记录
+	copies  map[int]*models.Copy
+	members map[int]*models.Member
+	loans   map[int]*models.Loan
+	holds   map[int]*models.Hold
+	next    Counters // 上一次保存时的计数器
+}
+
+// 把s恢复成上一次保存时的数据
+func (u undoLog) revert(s *Snapshot) {
+	if u.reset != nil {
+		*s = u.reset.Clone()
+		return
+	}
+	revertRecords(s.Books, u.books)
+	revertRecords(s.Copies, u.copies)
+	revertRecords(s.Members, u.members)
+	revertRecords(s.Loans, u.loans)
+	revertRecords(s.Holds, u.holds)
+	s.NextID = u.next.Book
+	s.NextCopyID = u.next.Copy
+	s.NextMemberID = u.next.Member
+	s.NextLoanID = u.next.Loan
+	s.NextHoldID = u.next.Hold
+}
+
+// 记录records[id]被修改之前的值，同一条记录只记录第一次
+func remember[T any](saved map[int]*T, records map[int]T, id int) map[int]*T {
+	if saved == nil {
+		saved = make(map[int]*T)
+	}
+	if _, done := saved[id]; done {
+		return saved
+	}
+	if v, exists := records[id]; exists {
+		saved[id] = &v
+	} else {
+		saved[id] = nil
+	}
+	return saved
+}
+
+func revertRecords[T any](records map[int]T, saved map[int]*T) {
+	for id, v := range saved {
+		if v == nil {
+			delete(records, id)
+		} else {
+			records[id] = *v
+		}
+	}
+}
+
+// 下面的函数修改数据并记录到pending和undo中，所有的修改都要通过它们
+
+func (lib *Library) putBook(book models.Book) {
+	lib.undo.books = remember(lib.undo.books, lib.Books, book.ID)
+	lib.Books[book.ID] = book
+	if lib.pending.Books == nil {
+		lib.pending.Books = make(map[int]models.Book)
+	}
+	lib.pending.Books[book.ID] = book
+}
+
+func (lib *Library) removeBook(id int) {
+	lib.undo.books = remember(lib.undo.books, lib.Books, id)
+	delete(lib.Books, id)
+	lib.pending.DeletedBooks = append(lib.pending.DeletedBooks, id)
+}
+
+func (lib *Library) putCopy(cp models.Copy) {
+	lib.undo.copies = remember(lib.undo.copies, lib.circulation.Copies, cp.ID)
+	lib.circulation.Copies[cp.ID] = cp
+	if lib.pending.Copies == nil {
+		lib.pending.Copies = make(map[int]models.Copy)
+	}
+	lib.pending.Copies[cp.ID] = cp
+}
+
+// 删除一本书的全部副本
+func (lib *Library) removeCopies(bookID int) {
+	for id, cp := range lib.circulation.Copies {
+		if cp.BookID == bookID {
+			lib.undo.copies = remember(lib.undo.copies, lib.circulation.Copies, id)
+			delete(lib.circulation.Copies, id)
+			lib.pending.DeletedCopies = append(lib.pending.DeletedCopies, id)
+		}
+	}
+}
+
+func (lib *Library) putMember(m models.Member) {
+	lib.undo.members = remember(lib.undo.members, lib.circulation.Members, m.ID)
+	lib.circulation.Members[m.ID] = m
+	if lib.pending.Members == nil {
+		lib.pending.Members = make(map[int]models.Member)
+	}
+	lib.pending.Members[m.ID] = m
+}
+
+func (lib *Library) removeMember(id int) {
+	lib.undo.members = remember(lib.undo.members, lib.circulation.Members, id)
+	delete(lib.circulation.Members, id)
+	lib.pending.DeletedMembers = append(lib.pending.DeletedMembers, id)
+}
+
+func (lib *Library) putLoan(loan models.Loan) {
+	lib.undo.loans = remember(lib.undo.loans, lib.circulation.Loans, loan.ID)
+	lib.circulation.Loans[loan.ID] = loan
+	if lib.pending.Loans == nil {
+		lib.pending.Loans = make(map[int]models.Loan)
+	}
+	lib.pending.Loans[loan.ID] = loan
+}
+
+func (lib *Library) putHold(hold models.Hold) {
+	lib.undo.holds = remember(lib.undo.holds, lib.circulation.Holds, hold.ID)
+	lib.circulation.Holds[hold.ID] = hold
+	if lib.pending.Holds == nil {
+		lib.pending.Holds = make(map[int]models.Hold)
+	}
+	lib.pending.Holds[hold.ID] = hold
+}
@@ -0,0 +1,149 @@
+package library
+
+import (
+	"errors"
+	"library-management/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errDiskFull = errors.New("disk full")
+
+// 保存在内存中的store，fail为true时Apply失败
+type memStore struct {
+	snap Snapshot
+	fail bool
+}
+
+func (s *memStore) Load() (Snapshot, error) { return s.snap.Clone(), nil }
+
+func (s *memStore) Apply(ch Change) error {
+	if s.fail {
+		return errDiskFull
+	}
+	s.snap.Apply(ch)
+	return nil
+}
+
+func (s *memStore) Close() error { return nil }
+
+// 保存失败时内存中的数据回到修改之前，和store中的一致
+func TestCommitRollback(t *testing.T) {
+	st := &memStore{snap: NewSnapshot()}
+	lib, err := Open(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bookID, _ := lib.AddBook("Go", "Alan", "9780134190440", models.Computer, 30)
+	lib.AddCopies(bookID, 1)
+	memberID, _ := lib.AddMember(models.Member{Name: "Ann", Tier: models.Basic, Status: models.Active})
+	loan, _ := lib.Checkout(memberID, bookID)
+	oldID, _ := lib.AddBook("Old", "Eve", "", models.Biography, 5)
+
+	for _, tc := range []struct {
+		name string
+		op   func() error
+	}{
+		{"AddBook", func() error {
+			id, err := lib.AddBook("New", "Bob", "0306406152", models.Fiction, 10)
+			if id != 0 {
+				t.Errorf("AddBook returned ID %d of a book that was rolled back", id)
+			}
+			return err
+		}},
+		{"AddBooks", func() error {
+			ids, err := lib.AddBooks([]models.Book{{Title: "A"}, {Title: "B", ISBN: "0306406152"}})
+			if ids != nil {
+				t.Errorf("AddBooks returned IDs %v of books that were rolled back", ids)
+			}
+			return err
+		}},
+		{"UpdateBookIf", func() error {
+			return lib.UpdateBookIf(bookID, nil, "Go 2", "Alan", "", models.Computer, 40)
+		}},
+		{"DeleteBookIf", func() error { return lib.DeleteBookIf(oldID, nil) }},
+		{"AddCopies", func() error {
+			_, err := lib.AddCopies(bookID, 2)
+			return err
+		}},
+		{"Return", func() error {
+			_, err := lib.Return(loan.CopyID)
+			return err
+		}},
+		{"Renew", func() error {
+			_, err := lib.Renew(loan.ID)
+			return err
+		}},
+		{"AddMember", func() error {
+			_, err := lib.AddMember(models.Member{Name: "Bo", Tier: models.Premium, Status: models.Active})
+			return err
+		}},
+		{"Restore", func() error { return lib.Restore(NewSnapshot()) }},
+	} {
+		before := lib.Snapshot()
+		st.fail = true
+		if err := tc.op(); !errors.Is(err, errDiskFull) {
+			t.Fatalf("%s: got error %v, want %v", tc.name, err, errDiskFull)
+		}
+		st.fail = false
+		if got := lib.Snapshot(); !reflect.DeepEqual(got, before) {
+			t.Errorf("%s: memory changed after a failed commit", tc.name)
+		}
+		if !reflect.DeepEqual(lib.Snapshot(), st.snap.Clone()) {
+			t.Errorf("%s: memory differs from the store", tc.name)
+		}
+	}
+
+	// ISBN索引也恢复了，之前失败的ISBN可以再添加
+	if _, err := lib.AddBook("New", "Bob", "0306406152", models.Fiction, 10); err != nil {
+		t.Fatalf("AddBook after rollback: %v", err)
+	}
+	if !reflect.DeepEqual(lib.Snapshot(), st.snap.Clone()) {
+		t.Error("memory differs from the store after a successful commit")
+	}
+}
+
+// 借书和预约之前处理的过期预约单独保存，之后的检查失败时不留在pending中
+func TestExpireHoldsCommittedBeforeValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		op   func(lib *Library, bookID int) error
+		want error
+	}{
+		{"Checkout", func(lib *Library, bookID int) error {
+			_, err := lib.Checkout(99, bookID)
+			return err
+		}, ErrMemberNotFound},
+		{"PlaceHold", func(lib *Library, bookID int) error {
+			_, err := lib.PlaceHold(99, bookID)
+			return err
+		}, ErrMemberNotFound},
+	} {
+		st := &memStore{snap: NewSnapshot()}
+		lib, _ := Open(st)
+		bookID, _ := lib.AddBook("Go", "Alan", "", models.Computer, 30)
+		lib.AddCopies(bookID, 1)
+		ann, _ := lib.AddMember(models.Member{Name: "Ann", Tier: models.Basic, Status: models.Active})
+		bob, _ := lib.AddMember(models.Member{Name: "Bob", Tier: models.Basic, Status: models.Active})
+		loan, _ := lib.Checkout(ann, bookID)
+		hold, _ := lib.PlaceHold(bob, bookID)
+		lib.Return(loan.CopyID)
+
+		// 取书期限已经过了，但是还没有处理
+		h := lib.circulation.Holds[hold.ID]
+		h.PickupBy = time.Now().Add(-time.Hour)
+		lib.circulation.Holds[hold.ID] = h
+		st.snap.Holds[hold.ID] = h
+
+		if err := tc.op(lib, bookID); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+		if !lib.pending.Empty() {
+			t.Errorf("%s: changes left in pending after a failed check: %+v", tc.name, lib.pending)
+		}
+		if st.snap.Holds[hold.ID].Status != models.Expired || !reflect.DeepEqual(lib.Snapshot(), st.snap.Clone()) {
+			t.Errorf("%s: expired hold not saved: %+v", tc.name, st.snap.Holds[hold.ID])
+		}
+	}
+}
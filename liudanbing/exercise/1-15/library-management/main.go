@@ -2,16 +2,59 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
+	"library-management/api"
 	"library-management/fileio"
 	"library-management/library"
 	"library-management/models"
+	"library-management/store"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv" // 字符串和数字的转换
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
-	lib := library.NewLibrary() // 创建一个新的图书馆
+	storeKind := flag.String("store", store.JSON, "where to keep the data: json, wal, sqlite or memory")
+	dbPath := flag.String("db", "", "file used by the json, wal and sqlite stores (default depends on -store)")
+	httpAddr := flag.String("http", "", "serve the REST API on this address (e.g. :8080) instead of the menu")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), commandUsage, "\nGlobal flags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 0 { // 有命令时只运行这个命令，不进入菜单
+		kind := ""
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "store" {
+				kind = *storeKind
+			}
+		})
+		os.Exit(runCommand(flag.Args(), kind, *dbPath))
+	}
+
+	lib, err := openLibrary(*storeKind, *dbPath) // 创建或者打开图书馆
+	if err != nil {
+		fmt.Println("Error opening library:", err)
+		os.Exit(1)
+	}
+	defer lib.Close()
+
+	if *httpAddr != "" {
+		if err := serveHTTP(lib, *httpAddr); err != nil {
+			fmt.Println("Error serving HTTP:", err)
+			lib.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	scanner := bufio.NewScanner(os.Stdin) // 创建一个新的扫描器
 	for {
 		fmt.Println("\n======== library management system ========")
@@ -23,8 +66,11 @@ func main() {
 		fmt.Println("5. List all books")
 		fmt.Println("6. Save to file")
 		fmt.Println("7. Load from file")
-		fmt.Println("8. Exit")
-		fmt.Print("Your choice(1-8): ")
+		fmt.Println("8. Circulation (borrow and return)")
+		fmt.Println("9. Members")
+		fmt.Println("10. Import / export books (CSV, MARC)")
+		fmt.Println("11. Exit")
+		fmt.Print("Your choice(1-11): ")
 
 		scanner.Scan() // 扫描输入
 		choice := scanner.Text() // 获取输入
@@ -45,6 +91,12 @@ func main() {
 		case "7":
 			loadData(lib, scanner)
 		case "8":
+			circulationMenu(lib, scanner)
+		case "9":
+			memberMenu(lib, scanner)
+		case "10":
+			bulkMenu(lib, scanner)
+		case "11":
 			fmt.Println("Goodbye!")
 			return
 		default:
@@ -53,6 +105,42 @@ func main() {
 	}
 }
 
+// 按照存储类型打开图书馆，memory时创建一个只在内存中的图书馆
+func openLibrary(kind, path string) (*library.Library, error) {
+	if path == "" {
+		path = store.DefaultPath(kind)
+	}
+	st, err := store.Open(kind, path)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return library.NewLibrary(), nil
+	}
+	fmt.Fprintf(os.Stderr, "Using %s store at %s, every change is saved automatically.\n", kind, path)
+	return library.Open(st)
+}
+
+// 提供REST接口，收到Ctrl+C或者SIGTERM时停止
+func serveHTTP(lib *library.Library, addr string) error {
+	server := &http.Server{Addr: addr, Handler: api.NewServer(lib)}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	fmt.Printf("Serving the REST API on %s, see /openapi.json\n", addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
 func addBook(lib *library.Library, scanner *bufio.Scanner) {
 	fmt.Print("pleace enter the title: ")
 	scanner.Scan()
@@ -62,6 +150,10 @@ func addBook(lib *library.Library, scanner *bufio.Scanner) {
 	scanner.Scan()
 	author := scanner.Text()
 
+	fmt.Print("please enter the ISBN-10 or ISBN-13 (leave blank if unknown): ")
+	scanner.Scan()
+	isbn := scanner.Text()
+
 	fmt.Println("choice the category number:")
 	for i, category := range models.CategoryNames {
 		fmt.Printf("%d. %s\n", i, category)
@@ -85,7 +177,11 @@ func addBook(lib *library.Library, scanner *bufio.Scanner) {
 		return
 	}
 
-	id := lib.AddBook(title, author, category, price)
+	id, err := lib.AddBook(title, author, isbn, category, price)
+	if err != nil {
+		fmt.Println("Error adding book:", err)
+		return
+	}
 	fmt.Printf("Book added with ID: %d\n", id)
 }
 
@@ -98,11 +194,11 @@ func deleteBook(lib *library.Library, scanner *bufio.Scanner) {
 		return
 	}
 
-	success := lib.DeleteBook(id)
-	if success {
-		fmt.Println("Book deleted successfully!")
+	err = lib.DeleteBook(id)
+	if err != nil {
+		fmt.Println("Error deleting book:", err)
 	} else {
-		fmt.Println("Book not found!")
+		fmt.Println("Book deleted successfully!")
 	}
 }
 
@@ -137,6 +233,14 @@ func updateBook(lib *library.Library, scanner *bufio.Scanner) {
 		author = book.Author
 	}
 
+	fmt.Printf("Current book ISBN: %s\n", book.ISBN)
+	fmt.Print("Enter a new ISBN (leave blank to indicate no changes): ")
+	scanner.Scan()
+	isbn := scanner.Text()
+	if isbn == "" {
+		isbn = book.ISBN
+	}
+
 	fmt.Printf("Current book category: %s\n", book.Category)
 	fmt.Println("Choose a new category(leave blank to indicate no changes):")
 	for i, category := range models.CategoryNames {
@@ -172,16 +276,41 @@ func updateBook(lib *library.Library, scanner *bufio.Scanner) {
 		}
 	}
 
-	success := lib.UpdateBook(id, title, author, category, price)
-	if success {
-		fmt.Println("Book updated successfully!")
+	err = lib.UpdateBook(id, title, author, isbn, category, price)
+	if err != nil {
+		fmt.Println("Error updating book:", err)
 	} else {
-		fmt.Println("Book not found!")
+		fmt.Println("Book updated successfully!")
 	}
 
 }
 
 func queryBooks(lib *library.Library, scanner *bufio.Scanner) {
+	fmt.Println("1. Search by ID")
+	fmt.Println("2. Search by ISBN")
+	fmt.Println("3. Search by title/author, category and price")
+	fmt.Print("Your choice(1-3): ")
+	scanner.Scan()
+	switch scanner.Text() {
+	case "1":
+		queryBookByID(lib, scanner)
+	case "2":
+		fmt.Print("Enter the ISBN-10 or ISBN-13: ")
+		scanner.Scan()
+		book, exists := lib.GetBookByISBN(scanner.Text())
+		if exists {
+			book.PrintDetails()
+		} else {
+			fmt.Println("Book not found!")
+		}
+	case "3":
+		searchBooks(lib, scanner)
+	default:
+		fmt.Println("Invalid choice!")
+	}
+}
+
+func queryBookByID(lib *library.Library, scanner *bufio.Scanner) {
 	fmt.Print("Enter the ID of the book you want to search: ")
 	scanner.Scan()
 	id, err := strconv.Atoi(scanner.Text())
@@ -198,6 +327,104 @@ func queryBooks(lib *library.Library, scanner *bufio.Scanner) {
 	}
 }
 
+func searchBooks(lib *library.Library, scanner *bufio.Scanner) {
+	var query library.Query
+
+	fmt.Print("Enter keywords in title or author (leave blank for all books): ")
+	scanner.Scan()
+	query.Text = scanner.Text()
+
+	if strings.TrimSpace(query.Text) != "" {
+		fmt.Print("Match every word separately instead of the whole phrase? (y/N): ")
+		scanner.Scan()
+		query.Tokens = strings.EqualFold(scanner.Text(), "y")
+	}
+
+	fmt.Println("Choose a category (leave blank for all categories):")
+	for i, category := range models.CategoryNames {
+		fmt.Printf("%d. %s\n", i, category)
+	}
+	fmt.Print("Enter the category number: ")
+	scanner.Scan()
+	if input := scanner.Text(); input != "" {
+		categoryInput, err := strconv.Atoi(input)
+		if err != nil || categoryInput < 0 || categoryInput >= len(models.CategoryNames) {
+			fmt.Println("Invalid category number!")
+			return
+		}
+		category := models.Category(categoryInput)
+		query.Category = &category
+	}
+
+	var ok bool
+	if query.MinPrice, ok = scanPrice(scanner, "Enter the minimum price (leave blank for no limit): "); !ok {
+		return
+	}
+	if query.MaxPrice, ok = scanPrice(scanner, "Enter the maximum price (leave blank for no limit): "); !ok {
+		return
+	}
+
+	fmt.Println("Sort by:")
+	for i, name := range library.SortFieldNames {
+		fmt.Printf("%d. %s\n", i, name)
+	}
+	fmt.Print("Enter the sort number (add - for descending, e.g. -3, leave blank for ID): ")
+	scanner.Scan()
+	if input := scanner.Text(); input != "" {
+		if strings.HasPrefix(input, "-") {
+			query.Desc = true
+			input = input[1:]
+		}
+		sortInput, err := strconv.Atoi(input)
+		if err != nil || sortInput < 0 || sortInput >= len(library.SortFieldNames) {
+			fmt.Println("Invalid sort number!")
+			return
+		}
+		query.SortBy = library.SortField(sortInput)
+	}
+
+	query.PageSize = 10
+	query.Page = 1
+	for {
+		result := lib.Search(query)
+		if result.Total == 0 {
+			fmt.Println("No books found!")
+			return
+		}
+
+		fmt.Printf("Found %d books, page %d/%d:\n", result.Total, result.Page, result.Pages)
+		for _, book := range result.Books {
+			book.PrintDetails()
+		}
+		if result.Page >= result.Pages {
+			return
+		}
+
+		fmt.Print("Press n for the next page, anything else to stop: ")
+		scanner.Scan()
+		if scanner.Text() != "n" {
+			return
+		}
+		query.Page++
+	}
+}
+
+// 读取一个价格，空输入返回0表示不限，输入非法时返回false
+func scanPrice(scanner *bufio.Scanner, prompt string) (float64, bool) {
+	fmt.Print(prompt)
+	scanner.Scan()
+	input := scanner.Text()
+	if input == "" {
+		return 0, true
+	}
+	price, err := strconv.ParseFloat(input, 64)
+	if err != nil || price < 0 {
+		fmt.Println("Invalid price!")
+		return 0, false
+	}
+	return price, true
+}
+
 func listBooks(lib *library.Library) {
 	books := lib.ListBooks()
 	if len(books) == 0 {
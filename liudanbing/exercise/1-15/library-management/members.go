@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"library-management/library"
+	"library-management/models"
+	"strconv"
+)
+
+func memberMenu(lib *library.Library, scanner *bufio.Scanner) {
+	fmt.Println("\n-------- members --------")
+	fmt.Println("1. Add a member")
+	fmt.Println("2. List members")
+	fmt.Println("3. Show a member and the books on loan")
+	fmt.Println("4. Update a member")
+	fmt.Println("5. Suspend a member")
+	fmt.Println("6. Reactivate a member")
+	fmt.Println("7. Delete a member")
+	fmt.Print("Your choice(1-7): ")
+	scanner.Scan()
+
+	switch scanner.Text() {
+	case "1":
+		addMember(lib, scanner)
+	case "2":
+		members := lib.ListMembers()
+		if len(members) == 0 {
+			fmt.Println("No members!")
+		}
+		for _, m := range members {
+			m.PrintDetails()
+		}
+	case "3":
+		id, ok := scanInt(scanner, "Enter the member ID: ")
+		if !ok {
+			return
+		}
+		m, exists := lib.GetMember(id)
+		if !exists {
+			fmt.Println("Member not found!")
+			return
+		}
+		m.PrintDetails()
+		rule := lib.Policy.Rule(m)
+		loans := lib.MemberLoans(id)
+		fmt.Printf("Books on loan: %d/%d, loan days: %d, renewals: %d\n",
+			len(loans), rule.MaxLoans, rule.LoanDays, rule.MaxRenewals)
+		for _, loan := range loans {
+			loan.PrintDetails()
+		}
+		for _, hold := range lib.MemberHolds(id) {
+			hold.PrintDetails()
+		}
+	case "4":
+		updateMember(lib, scanner)
+	case "5", "6":
+		status := models.Suspended
+		if scanner.Text() == "6" {
+			status = models.Active
+		}
+		id, ok := scanInt(scanner, "Enter the member ID: ")
+		if !ok {
+			return
+		}
+		if err := lib.SetMemberStatus(id, status); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Member is now %s!\n", status)
+	case "7":
+		id, ok := scanInt(scanner, "Enter the member ID: ")
+		if !ok {
+			return
+		}
+		if err := lib.DeleteMember(id); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Println("Member deleted successfully!")
+	default:
+		fmt.Println("Invalid choice!")
+	}
+}
+
+func addMember(lib *library.Library, scanner *bufio.Scanner) {
+	var m models.Member
+	fmt.Print("Enter the name: ")
+	scanner.Scan()
+	m.Name = scanner.Text()
+	fmt.Print("Enter the email: ")
+	scanner.Scan()
+	m.Email = scanner.Text()
+	fmt.Print("Enter the phone: ")
+	scanner.Scan()
+	m.Phone = scanner.Text()
+	fmt.Print("Enter the address: ")
+	scanner.Scan()
+	m.Address = scanner.Text()
+
+	tier, ok := scanTier(scanner, models.Basic)
+	if !ok {
+		return
+	}
+	m.Tier = tier
+
+	id, err := lib.AddMember(m)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Member added with ID: %d\n", id)
+}
+
+func updateMember(lib *library.Library, scanner *bufio.Scanner) {
+	id, ok := scanInt(scanner, "Enter the member ID: ")
+	if !ok {
+		return
+	}
+	m, exists := lib.GetMember(id)
+	if !exists {
+		fmt.Println("Member not found!")
+		return
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"name", &m.Name},
+		{"email", &m.Email},
+		{"phone", &m.Phone},
+		{"address", &m.Address},
+	}
+	for _, f := range fields {
+		fmt.Printf("Current %s: %s\n", f.name, *f.value)
+		fmt.Printf("Enter a new %s (leave blank to indicate no changes): ", f.name)
+		scanner.Scan()
+		if input := scanner.Text(); input != "" {
+			*f.value = input
+		}
+	}
+
+	fmt.Printf("Current tier: %s\n", m.Tier)
+	if m.Tier, ok = scanTier(scanner, m.Tier); !ok {
+		return
+	}
+
+	fmt.Printf("Current loan limit: %d (0 means the tier default)\n", m.MaxLoans)
+	fmt.Print("Enter a new loan limit (leave blank to indicate no changes): ")
+	scanner.Scan()
+	if input := scanner.Text(); input != "" {
+		maxLoans, err := strconv.Atoi(input)
+		if err != nil || maxLoans < 0 {
+			fmt.Println("Invalid loan limit!")
+			return
+		}
+		m.MaxLoans = maxLoans
+	}
+
+	if err := lib.UpdateMember(m); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("Member updated successfully!")
+}
+
+// 读取会员等级，空输入返回def
+func scanTier(scanner *bufio.Scanner, def models.Tier) (models.Tier, bool) {
+	fmt.Println("Choose a tier:")
+	for i, name := range models.TierNames {
+		fmt.Printf("%d. %s\n", i, name)
+	}
+	fmt.Printf("Enter the tier number (leave blank for %s): ", def)
+	scanner.Scan()
+	input := scanner.Text()
+	if input == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(input)
+	if err != nil || !models.Tier(n).Valid() {
+		fmt.Println("Invalid tier number!")
+		return 0, false
+	}
+	return models.Tier(n), true
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// CopyStatus 表示一本实体书(副本)的状态
+type CopyStatus int
+
+const (
+	OnShelf CopyStatus = iota // 在架上，可以借出
+	OnLoan                    // 已借出
+	OnHold                    // 为预约的会员保留，等待取书
+)
+
+var CopyStatusNames = []string{
+	"OnShelf",
+	"OnLoan",
+	"OnHold",
+}
+
+func (s CopyStatus) String() string {
+	if int(s) >= 0 && int(s) < len(CopyStatusNames) {
+		return CopyStatusNames[s]
+	}
+	return "Unknown"
+}
+
+// Copy 是一本书(Book)的一个实体副本，同一本书可以有多个副本
+type Copy struct {
+	ID     int
+	BookID int
+	Status CopyStatus
+	LoanID int // 当前的借阅记录，在架上时为0
+	HoldID int // 为哪个预约保留，不是OnHold时为0
+}
+
+// Loan 是一次借阅记录
+type Loan struct {
+	ID         int
+	CopyID     int
+	BookID     int
+	MemberID   int
+	CheckedOut time.Time
+	Due        time.Time
+	Returned   time.Time // 还书时间，未还时为零值
+	Renewals   int       // 已续借的次数
+	Fine       float64   // 还书时产生的逾期罚款
+}
+
+// Active 表示这本书还没有还
+func (l Loan) Active() bool {
+	return l.Returned.IsZero()
+}
+
+// Overdue 判断在now时是否已经逾期未还
+func (l Loan) Overdue(now time.Time) bool {
+	return l.Active() && now.After(l.Due)
+}
+
+// DaysOverdue 返回逾期的天数，不足一天按一天算；已还的书按还书时间计算
+func (l Loan) DaysOverdue(now time.Time) int {
+	end := now
+	if !l.Active() {
+		end = l.Returned
+	}
+	if !end.After(l.Due) {
+		return 0
+	}
+	return int((end.Sub(l.Due) + 24*time.Hour - 1) / (24 * time.Hour))
+}
+
+func (l Loan) PrintDetails() {
+	status := "借出中"
+	if !l.Active() {
+		status = "已还 " + l.Returned.Format("2006-01-02")
+	}
+	fmt.Printf("借阅ID: %d, 书ID: %d, 副本ID: %d, 会员ID: %d, 借出: %s, 应还: %s, 续借: %d次, 状态: %s, 罚款: %.2f\n",
+		l.ID, l.BookID, l.CopyID, l.MemberID, l.CheckedOut.Format("2006-01-02"), l.Due.Format("2006-01-02"),
+		l.Renewals, status, l.Fine)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// HoldStatus 表示预约的状态
+type HoldStatus int
+
+const (
+	Waiting   HoldStatus = iota // 排队等待归还的副本
+	Ready                       // 已经分配了副本，等待会员来取
+	Fulfilled                   // 会员已经借走
+	Expired                     // 超过取书期限没有来取
+	Cancelled                   // 被取消
+)
+
+var HoldStatusNames = []string{
+	"Waiting",
+	"Ready",
+	"Fulfilled",
+	"Expired",
+	"Cancelled",
+}
+
+func (s HoldStatus) String() string {
+	if int(s) >= 0 && int(s) < len(HoldStatusNames) {
+		return HoldStatusNames[s]
+	}
+	return "Unknown"
+}
+
+// Hold 是一个会员对一本书(不是某个副本)的预约
+type Hold struct {
+	ID       int
+	BookID   int
+	MemberID int
+	Placed   time.Time
+	Status   HoldStatus
+	CopyID   int       // 分配的副本，Ready之后才有
+	PickupBy time.Time // 取书的截止时间，Ready之后才有
+}
+
+// Active 表示预约还在排队或者等待取书
+func (h Hold) Active() bool {
+	return h.Status == Waiting || h.Status == Ready
+}
+
+func (h Hold) PrintDetails() {
+	fmt.Printf("预约ID: %d, 书ID: %d, 会员ID: %d, 预约时间: %s, 状态: %s",
+		h.ID, h.BookID, h.MemberID, h.Placed.Format("2006-01-02 15:04"), h.Status)
+	if h.Status == Ready {
+		fmt.Printf(", 副本ID: %d, 取书截止: %s", h.CopyID, h.PickupBy.Format("2006-01-02 15:04"))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidISBN = errors.New("invalid ISBN")
+
+// 去掉ISBN中的连字符和空格，X统一为大写
+func cleanISBN(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '-' || r == ' ':
+		case r == 'x':
+			b.WriteRune('X')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ISBN-10的校验位：前9位分别乘10到2，校验位使和为11的倍数，10写成X
+func isbn10Check(first9 string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(first9[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// ISBN-13的校验位：前12位交替乘1和3，校验位使和为10的倍数
+func isbn13Check(first12 string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(first12[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ValidISBN10 检查ISBN-10的格式和校验位，允许连字符
+func ValidISBN10(s string) bool {
+	s = cleanISBN(s)
+	if len(s) != 10 || !allDigits(s[:9]) {
+		return false
+	}
+	return s[9] == isbn10Check(s[:9])
+}
+
+// ValidISBN13 检查ISBN-13的格式和校验位，允许连字符
+func ValidISBN13(s string) bool {
+	s = cleanISBN(s)
+	if len(s) != 13 || !allDigits(s) {
+		return false
+	}
+	return s[12] == isbn13Check(s[:12])
+}
+
+// ISBN10To13 把ISBN-10转换成978开头的ISBN-13
+func ISBN10To13(s string) (string, error) {
+	if !ValidISBN10(s) {
+		return "", ErrInvalidISBN
+	}
+	first12 := "978" + cleanISBN(s)[:9]
+	return first12 + string(isbn13Check(first12)), nil
+}
+
+// ISBN13To10 把978开头的ISBN-13转换成ISBN-10，979开头的没有对应的ISBN-10
+func ISBN13To10(s string) (string, error) {
+	s = cleanISBN(s)
+	if !ValidISBN13(s) || !strings.HasPrefix(s, "978") {
+		return "", ErrInvalidISBN
+	}
+	first9 := s[3:12]
+	return first9 + string(isbn10Check(first9)), nil
+}
+
+// NormalizeISBN 检查ISBN-10或ISBN-13，统一转换成不带连字符的ISBN-13，
+// 同一本书的两种写法得到相同的结果
+func NormalizeISBN(s string) (string, error) {
+	s = cleanISBN(s)
+	switch {
+	case ValidISBN13(s):
+		return s, nil
+	case ValidISBN10(s):
+		return ISBN10To13(s)
+	}
+	return "", ErrInvalidISBN
+}
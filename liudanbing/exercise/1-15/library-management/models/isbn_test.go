@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidISBN(t *testing.T) {
+	for _, tc := range []struct {
+		isbn             string
+		valid10, valid13 bool
+	}{
+		{"0306406152", true, false},
+		{"0-306-40615-2", true, false},
+		{"080442957X", true, false}, // 校验位10写成X
+		{"080442957x", true, false},
+		{"0306406153", false, false}, // 校验位错误
+		{"03064061X2", false, false}, // X只能是校验位
+		{"9780306406157", false, true},
+		{"978-0-306-40615-7", false, true},
+		{"979-10-90636-07-1", false, true},
+		{"9780306406158", false, false},
+		{"978030640615X", false, false},
+		{"12345", false, false},
+		{"", false, false},
+	} {
+		if got := ValidISBN10(tc.isbn); got != tc.valid10 {
+			t.Errorf("ValidISBN10(%q) = %v", tc.isbn, got)
+		}
+		if got := ValidISBN13(tc.isbn); got != tc.valid13 {
+			t.Errorf("ValidISBN13(%q) = %v", tc.isbn, got)
+		}
+	}
+}
+
+func TestISBNConversion(t *testing.T) {
+	for _, tc := range []struct {
+		isbn10, isbn13 string
+	}{
+		{"0306406152", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"0134190440", "9780134190440"},
+	} {
+		if got, err := ISBN10To13(tc.isbn10); err != nil || got != tc.isbn13 {
+			t.Errorf("ISBN10To13(%q) = %q, %v, want %q", tc.isbn10, got, err, tc.isbn13)
+		}
+		if got, err := ISBN13To10(tc.isbn13); err != nil || got != tc.isbn10 {
+			t.Errorf("ISBN13To10(%q) = %q, %v, want %q", tc.isbn13, got, err, tc.isbn10)
+		}
+	}
+
+	for _, bad := range []string{"0306406153", "9781234567890"} {
+		if _, err := ISBN10To13(bad); !errors.Is(err, ErrInvalidISBN) {
+			t.Errorf("ISBN10To13(%q): %v", bad, err)
+		}
+	}
+	// 979开头的ISBN-13没有对应的ISBN-10
+	for _, bad := range []string{"9791090636071", "9780306406158", "0306406152"} {
+		if _, err := ISBN13To10(bad); !errors.Is(err, ErrInvalidISBN) {
+			t.Errorf("ISBN13To10(%q): %v", bad, err)
+		}
+	}
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"0-306-40615-2", "9780306406157"},
+		{"978 0 306 40615 7", "9780306406157"},
+		{"080442957x", "9780804429573"},
+		{"979-10-90636-07-1", "9791090636071"},
+		{"0306406153", ""},
+		{"not an isbn", ""},
+	} {
+		got, err := NormalizeISBN(tc.in)
+		if got != tc.want || (tc.want == "") != (err != nil) {
+			t.Errorf("NormalizeISBN(%q) = %q, %v, want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
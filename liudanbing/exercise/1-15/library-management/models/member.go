@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tier 表示会员等级，不同等级的借阅规则不同
+type Tier int
+
+const (
+	Basic Tier = iota
+	Standard
+	Premium
+)
+
+var TierNames = []string{
+	"Basic",
+	"Standard",
+	"Premium",
+}
+
+func (t Tier) String() string {
+	if int(t) >= 0 && int(t) < len(TierNames) {
+		return TierNames[t]
+	}
+	return "Unknown"
+}
+
+// Valid 判断是否是已定义的等级
+func (t Tier) Valid() bool {
+	return int(t) >= 0 && int(t) < len(TierNames)
+}
+
+// MemberStatus 表示会员的状态，被停用的会员不能借书
+type MemberStatus int
+
+const (
+	Active MemberStatus = iota
+	Suspended
+)
+
+var MemberStatusNames = []string{
+	"Active",
+	"Suspended",
+}
+
+func (s MemberStatus) String() string {
+	if int(s) >= 0 && int(s) < len(MemberStatusNames) {
+		return MemberStatusNames[s]
+	}
+	return "Unknown"
+}
+
+// Member 表示图书馆的会员(借书的读者)
+type Member struct {
+	ID      int
+	Name    string
+	Email   string
+	Phone   string
+	Address string
+	Tier    Tier
+	Status  MemberStatus
+	Joined  time.Time
+
+	MaxLoans int     // 最多同时借的书，0表示使用等级的默认值
+	Fines    float64 // 未缴纳的罚款
+}
+
+func (m Member) PrintDetails() {
+	maxLoans := "按等级"
+	if m.MaxLoans > 0 {
+		maxLoans = fmt.Sprint(m.MaxLoans)
+	}
+	fmt.Printf("ID: %d, 姓名: %s, 邮箱: %s, 电话: %s, 地址: %s, 等级: %s, 状态: %s, 借阅上限: %s, 未缴罚款: %.2f\n",
+		m.ID, m.Name, m.Email, m.Phone, m.Address, m.Tier, m.Status, maxLoans, m.Fines)
+}
@@ -1,6 +1,10 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type Category int
 
@@ -29,6 +33,20 @@ func (c Category) String() string {
 	return "Unknown"
 }
 
+// ParseCategory 把类别名称(不区分大小写)或者编号转换成Category
+func ParseCategory(s string) (Category, error) {
+	s = strings.TrimSpace(s)
+	for i, name := range CategoryNames {
+		if strings.EqualFold(s, name) {
+			return Category(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(CategoryNames) {
+		return Category(n), nil
+	}
+	return 0, fmt.Errorf("unknown category %q", s)
+}
+
 type Printable interface {
 	PrintDetails()
 }
@@ -39,9 +57,17 @@ type Book struct {
 	Author string
 	Category Category
 	Price float64
+	ISBN string // 统一保存为不带连字符的ISBN-13，可以为空
 }
 
 func (b Book) PrintDetails() {
-    fmt.Printf("ID: %d, 书名: %s, 作者: %s, 类别: %s, 价格: %.2f\n",
+    fmt.Printf("ID: %d, 书名: %s, 作者: %s, 类别: %s, 价格: %.2f",
         b.ID, b.Title, b.Author, b.Category, b.Price)
+    if b.ISBN != "" {
+        fmt.Printf(", ISBN: %s", b.ISBN)
+        if isbn10, err := ISBN13To10(b.ISBN); err == nil {
+            fmt.Printf(" (ISBN-10: %s)", isbn10)
+        }
+    }
+    fmt.Println()
 }
\ No newline at end of file
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"errors"
+	"io/fs"
+	"library-management/fileio"
+	"library-management/library"
+)
+
+// JSONStore 每次修改之后把全部数据原子地写入一个JSON文件，适合数据量不大的情况
+type JSONStore struct {
+	filename string
+	snap     library.Snapshot
+}
+
+// OpenJSON 使用filename保存数据，文件不存在时在第一次修改时创建
+func OpenJSON(filename string) *JSONStore {
+	return &JSONStore{filename: filename, snap: library.NewSnapshot()}
+}
+
+func (s *JSONStore) Load() (library.Snapshot, error) {
+	snap, err := fileio.LoadSnapshot(s.filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		snap, err = library.NewSnapshot(), nil
+	}
+	if err != nil {
+		return library.Snapshot{}, err
+	}
+	s.snap = snap.Clone()
+	return snap, nil
+}
+
+// Apply 先把修改应用到副本上并写入文件，写入成功之后才替换内存中的数据
+func (s *JSONStore) Apply(ch library.Change) error {
+	snap := s.snap.Clone()
+	snap.Apply(ch)
+	if err := fileio.WriteSnapshot(s.filename, snap, 0); err != nil { // 每次修改都写文件，不保留备份
+		return err
+	}
+	s.snap = snap
+	return nil
+}
+
+func (s *JSONStore) Close() error {
+	return nil
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"library-management/library"
+	"library-management/models"
+
+	_ "modernc.org/sqlite" // 纯Go的SQLite驱动，不需要cgo
+)
+
+// 书籍按列保存，方便用其它工具查询；副本、会员、借阅和预约保存为JSON
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS books (
+	id       INTEGER PRIMARY KEY,
+	title    TEXT NOT NULL,
+	author   TEXT NOT NULL,
+	isbn     TEXT NOT NULL,
+	category INTEGER NOT NULL,
+	price    REAL NOT NULL
+);
+CREATE TABLE IF NOT EXISTS copies  (id INTEGER PRIMARY KEY, data TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS members (id INTEGER PRIMARY KEY, data TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS loans   (id INTEGER PRIMARY KEY, data TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS holds   (id INTEGER PRIMARY KEY, data TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS counters (name TEXT PRIMARY KEY, value INTEGER NOT NULL);
+`
+
+// SQLiteStore 把数据保存在SQLite数据库中，每次修改是一个事务
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite 打开或者创建数据库文件
+func OpenSQLite(filename string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1) // SQLite同时只能有一个写入者
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Load() (library.Snapshot, error) {
+	snap := library.NewSnapshot()
+
+	rows, err := s.db.Query(`SELECT id, title, author, isbn, category, price FROM books`)
+	if err != nil {
+		return snap, err
+	}
+	for rows.Next() {
+		var b models.Book
+		if err := rows.Scan(&b.ID, &b.Title, &b.Author, &b.ISBN, &b.Category, &b.Price); err != nil {
+			rows.Close()
+			return snap, err
+		}
+		snap.Books[b.ID] = b
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return snap, err
+	}
+
+	if err := loadJSONTable(s.db, "copies", snap.Copies); err != nil {
+		return snap, err
+	}
+	if err := loadJSONTable(s.db, "members", snap.Members); err != nil {
+		return snap, err
+	}
+	if err := loadJSONTable(s.db, "loans", snap.Loans); err != nil {
+		return snap, err
+	}
+	if err := loadJSONTable(s.db, "holds", snap.Holds); err != nil {
+		return snap, err
+	}
+
+	counters := map[string]*int{
+		"book":   &snap.NextID,
+		"copy":   &snap.NextCopyID,
+		"member": &snap.NextMemberID,
+		"loan":   &snap.NextLoanID,
+		"hold":   &snap.NextHoldID,
+	}
+	rows, err = s.db.Query(`SELECT name, value FROM counters`)
+	if err != nil {
+		return snap, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var value int
+		if err := rows.Scan(&name, &value); err != nil {
+			return snap, err
+		}
+		if p, ok := counters[name]; ok {
+			*p = value
+		}
+	}
+	return snap, rows.Err()
+}
+
+// 读取一个按JSON保存的表
+func loadJSONTable[T any](db *sql.DB, table string, into map[int]T) error {
+	rows, err := db.Query(`SELECT id, data FROM ` + table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return err
+		}
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		into[id] = v
+	}
+	return rows.Err()
+}
+
+func (s *SQLiteStore) Apply(ch library.Change) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Commit之后Rollback什么都不做
+
+	if ch.Reset != nil {
+		for _, table := range []string{"books", "copies", "members", "loans", "holds", "counters"} {
+			if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+				return err
+			}
+		}
+		reset := ch.Reset.Clone()
+		if err := putAll(tx, reset.Books, reset.Copies, reset.Members, reset.Loans, reset.Holds); err != nil {
+			return err
+		}
+		if err := putCounters(tx, library.Counters{
+			Book: reset.NextID, Copy: reset.NextCopyID, Member: reset.NextMemberID,
+			Loan: reset.NextLoanID, Hold: reset.NextHoldID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := putAll(tx, ch.Books, ch.Copies, ch.Members, ch.Loans, ch.Holds); err != nil {
+		return err
+	}
+	deletes := []struct {
+		table string
+		ids   []int
+	}{
+		{"books", ch.DeletedBooks},
+		{"copies", ch.DeletedCopies},
+		{"members", ch.DeletedMembers},
+	}
+	for _, d := range deletes {
+		for _, id := range d.ids {
+			if _, err := tx.Exec(`DELETE FROM `+d.table+` WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+	}
+	if ch.Next.Book > 0 {
+		if err := putCounters(tx, ch.Next); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func putAll(tx *sql.Tx, books map[int]models.Book, copies map[int]models.Copy,
+	members map[int]models.Member, loans map[int]models.Loan, holds map[int]models.Hold) error {
+	for _, b := range books {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO books (id, title, author, isbn, category, price) VALUES (?, ?, ?, ?, ?, ?)`,
+			b.ID, b.Title, b.Author, b.ISBN, int(b.Category), b.Price); err != nil {
+			return err
+		}
+	}
+	if err := putJSONTable(tx, "copies", copies); err != nil {
+		return err
+	}
+	if err := putJSONTable(tx, "members", members); err != nil {
+		return err
+	}
+	if err := putJSONTable(tx, "loans", loans); err != nil {
+		return err
+	}
+	return putJSONTable(tx, "holds", holds)
+}
+
+func putJSONTable[T any](tx *sql.Tx, table string, values map[int]T) error {
+	for id, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO `+table+` (id, data) VALUES (?, ?)`, id, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putCounters(tx *sql.Tx, next library.Counters) error {
+	counters := map[string]int{
+		"book":   next.Book,
+		"copy":   next.Copy,
+		"member": next.Member,
+		"loan":   next.Loan,
+		"hold":   next.Hold,
+	}
+	for name, value := range counters {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO counters (name, value) VALUES (?, ?)`, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,44 @@
+// store 包含 library.Store 的几种实现：
+//   - json:   每次修改都重写整个JSON文件，格式和菜单中保存的文件一样
+//   - wal:    修改追加到日志(write-ahead log)文件中，定期合并成快照
+//   - sqlite: 保存在SQLite数据库中(纯Go的驱动，不需要cgo)
+package store
+
+import (
+	"fmt"
+	"library-management/library"
+)
+
+// 支持的存储类型
+const (
+	Memory = "memory" // 只保存在内存中，需要手动保存到文件
+	JSON   = "json"
+	WAL    = "wal"
+	SQLite = "sqlite"
+)
+
+// Open 按照类型打开存储，path是文件的路径，memory时返回nil
+func Open(kind, path string) (library.Store, error) {
+	switch kind {
+	case Memory, "":
+		return nil, nil
+	case JSON:
+		return OpenJSON(path), nil
+	case WAL:
+		return OpenWAL(path, DefaultCompactEvery), nil
+	case SQLite:
+		return OpenSQLite(path)
+	}
+	return nil, fmt.Errorf("unknown store %q, use memory, json, wal or sqlite", kind)
+}
+
+// DefaultPath 返回每种存储默认的文件路径
+func DefaultPath(kind string) string {
+	switch kind {
+	case WAL:
+		return "library.snapshot.json"
+	case SQLite:
+		return "library.db"
+	}
+	return "books.json"
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"encoding/json"
+	"library-management/library"
+	"library-management/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Snapshot编码为JSON之后比较，时间经过JSON之后会丢掉单调时钟
+func snapshotJSON(t *testing.T, snap library.Snapshot) string {
+	t.Helper()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// 通过Library做一些修改，覆盖所有种类的记录
+func populate(t *testing.T, lib *library.Library) {
+	t.Helper()
+
+	dune, err := lib.AddBook("Dune", "Herbert", "0306406152", models.Fiction, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone, _ := lib.AddBook("Gone", "", "", models.Science, 1)
+	if err := lib.DeleteBook(gone); err != nil {
+		t.Fatal(err)
+	}
+	lib.AddCopies(dune, 1)
+	ann, _ := lib.AddMember(models.Member{Name: "Ann", Tier: models.Basic})
+	bob, _ := lib.AddMember(models.Member{Name: "Bob", Tier: models.Premium})
+	if _, err := lib.Checkout(ann, dune); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lib.PlaceHold(bob, dune); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.UpdateBook(dune, "Dune", "Frank Herbert", "0306406152", models.Fiction, 12); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 每种存储关闭之后重新打开，数据和关闭之前一样
+func TestReopen(t *testing.T) {
+	for _, kind := range []string{JSON, WAL, SQLite} {
+		path := filepath.Join(t.TempDir(), DefaultPath(kind))
+		st, err := Open(kind, path)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		lib, err := library.Open(st)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		populate(t, lib)
+		want := snapshotJSON(t, lib.Snapshot())
+		if err := lib.Close(); err != nil {
+			t.Fatalf("%s: close: %v", kind, err)
+		}
+
+		st, _ = Open(kind, path)
+		lib, err = library.Open(st)
+		if err != nil {
+			t.Fatalf("%s: reopen: %v", kind, err)
+		}
+		if got := snapshotJSON(t, lib.Snapshot()); got != want {
+			t.Errorf("%s: reopened\n%s\nwant\n%s", kind, got, want)
+		}
+
+		// 重新打开之后计数器继续，ID不会重复使用
+		if id, _ := lib.AddBook("Next", "", "", models.Fiction, 1); id != 3 {
+			t.Errorf("%s: next book id %d, want 3", kind, id)
+		}
+		lib.Close()
+	}
+}
+
+func TestOpenUnknownStore(t *testing.T) {
+	if _, err := Open("csv", "books.csv"); err == nil {
+		t.Error("unknown store accepted")
+	}
+	if st, err := Open(Memory, ""); st != nil || err != nil {
+		t.Errorf("memory store = %v, %v, want nil", st, err)
+	}
+}
+
+// 没有合并的修改只在日志中，加载时重放
+func TestWALReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.json")
+	lib, _ := library.Open(OpenWAL(path, 1000))
+	populate(t, lib)
+	want := snapshotJSON(t, lib.Snapshot())
+	lib.Close()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("snapshot written before compaction: %v", err)
+	}
+	data, _ := os.ReadFile(path + ".wal")
+
+	for _, tc := range []struct {
+		name string
+		log  string
+		ok   bool
+	}{
+		{"complete", string(data), true},
+		{"torn last entry", string(data) + `{"books":{"9":`, true}, // 写到一半时崩溃，丢弃
+		{"corrupt entry", string(data) + "not json\n", false},
+	} {
+		os.WriteFile(path+".wal", []byte(tc.log), 0644)
+		st := OpenWAL(path, 1000)
+		snap, err := st.Load()
+		if tc.ok != (err == nil) {
+			t.Fatalf("%s: Load = %v", tc.name, err)
+		}
+		if !tc.ok {
+			continue
+		}
+		if got := snapshotJSON(t, snap); got != want {
+			t.Errorf("%s: replayed\n%s\nwant\n%s", tc.name, got, want)
+		}
+		st.Close()
+		// 不完整的日志在加载时被截掉，之后的修改接在完整的日志后面
+		if log, _ := os.ReadFile(path + ".wal"); string(log) != string(data) {
+			t.Errorf("%s: log not truncated to the complete entries", tc.name)
+		}
+	}
+}
+
+// 达到一定次数之后把日志合并到快照中
+func TestWALCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.json")
+	lib, _ := library.Open(OpenWAL(path, 3))
+	for i := 0; i < 4; i++ {
+		if _, err := lib.AddBook("Book", "", "", models.Fiction, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lib.Close()
+
+	// 前3次修改在快照中，第4次在日志中
+	log, _ := os.ReadFile(path + ".wal")
+	var ch library.Change
+	if err := json.Unmarshal(log, &ch); err != nil || len(ch.Books) != 1 || ch.Books[4].Price != 3 {
+		t.Errorf("log after compaction: %q, %v", log, err)
+	}
+	st := OpenJSON(path)
+	if snap, err := st.Load(); err != nil || len(snap.Books) != 3 || snap.NextID != 4 {
+		t.Errorf("snapshot after compaction: %+v, %v", snap, err)
+	}
+
+	lib, _ = library.Open(OpenWAL(path, 3))
+	if books := lib.ListBooks(); len(books) != 4 {
+		t.Errorf("reloaded %d books, want 4", len(books))
+	}
+	lib.Close()
+}
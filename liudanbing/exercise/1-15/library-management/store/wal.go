@@ -0,0 +1,155 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"library-management/fileio"
+	"library-management/library"
+	"os"
+)
+
+// DefaultCompactEvery 是默认多少次修改之后把日志合并到快照中
+const DefaultCompactEvery = 100
+
+// WALStore 把每次修改作为一行JSON追加到日志文件(快照文件名加上.wal)中并fsync，
+// 日志达到一定长度后写一个新的快照并清空日志。加载时先读快照再重放日志
+type WALStore struct {
+	snapshotFile string
+	logFile      string
+	compactEvery int
+
+	snap    library.Snapshot
+	log     *os.File
+	entries int // 日志中的修改数
+}
+
+// OpenWAL 使用snapshotFile和snapshotFile.wal保存数据，compactEvery次修改之后合并一次
+func OpenWAL(snapshotFile string, compactEvery int) *WALStore {
+	if compactEvery <= 0 {
+		compactEvery = DefaultCompactEvery
+	}
+	return &WALStore{
+		snapshotFile: snapshotFile,
+		logFile:      snapshotFile + ".wal",
+		compactEvery: compactEvery,
+		snap:         library.NewSnapshot(),
+	}
+}
+
+func (s *WALStore) Load() (library.Snapshot, error) {
+	snap, err := fileio.LoadSnapshot(s.snapshotFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		snap, err = library.NewSnapshot(), nil
+	}
+	if err != nil {
+		return library.Snapshot{}, err
+	}
+
+	data, err := os.ReadFile(s.logFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return library.Snapshot{}, err
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	valid := 0 // 完整的日志的字节数
+	s.entries = 0
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			// 最后一行没有换行符，是写到一半时崩溃留下的，丢弃
+			break
+		}
+		var ch library.Change
+		if err := json.Unmarshal(line, &ch); err != nil {
+			return library.Snapshot{}, fmt.Errorf("%s: entry %d: %w", s.logFile, i+1, err)
+		}
+		snap.Apply(ch)
+		s.entries++
+		valid += len(line) + 1
+	}
+
+	if s.log, err = os.OpenFile(s.logFile, os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+		return library.Snapshot{}, err
+	}
+	if err := s.log.Truncate(int64(valid)); err != nil {
+		return library.Snapshot{}, err
+	}
+	if _, err := s.log.Seek(int64(valid), 0); err != nil {
+		return library.Snapshot{}, err
+	}
+
+	s.snap = snap.Clone()
+	return snap, nil
+}
+
+// Apply 把修改追加到日志中并fsync。写入失败时把日志截断回写入之前的长度，
+// 这样失败的修改不会在下次加载时被重放
+func (s *WALStore) Apply(ch library.Change) error {
+	if s.log == nil {
+		return errors.New("wal store is not loaded")
+	}
+	line, err := json.Marshal(ch)
+	if err != nil {
+		return err
+	}
+	offset, err := s.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if err := s.append(line); err != nil {
+		if terr := s.log.Truncate(offset); terr != nil {
+			return errors.Join(err, terr)
+		}
+		if _, serr := s.log.Seek(offset, io.SeekStart); serr != nil {
+			return errors.Join(err, serr)
+		}
+		return err
+	}
+
+	s.snap.Apply(ch)
+	s.entries++
+	if s.entries >= s.compactEvery {
+		s.Compact() // 修改已经保存在日志中，合并失败不影响这次修改，下次修改时再合并
+	}
+	return nil
+}
+
+// 写入一行日志并fsync
+func (s *WALStore) append(line []byte) error {
+	w := bufio.NewWriter(s.log)
+	w.Write(line)
+	w.WriteByte('\n')
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return s.log.Sync()
+}
+
+// Compact 把当前的数据写成新的快照并清空日志。
+// 快照是原子地写入的；日志中的修改重复应用结果不变，
+// 所以任何时候崩溃都能从旧快照加日志或者新快照加日志恢复
+func (s *WALStore) Compact() error {
+	if err := fileio.WriteSnapshot(s.snapshotFile, s.snap, 0); err != nil {
+		return err
+	}
+	if err := s.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.log.Seek(0, 0); err != nil {
+		return err
+	}
+	s.entries = 0
+	return s.log.Sync()
+}
+
+func (s *WALStore) Close() error {
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
@@ -9,14 +9,23 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/xtaci/kcp-go/v5"
 )
 
 var serverIp  string
 var serverPort int
+var langTag    string
+var transport  string
+var socketPath string
 
 func init() {
 	flag.StringVar(&serverIp, "ip", "127.0.0.1", "设置服务器IP地址(默认是127.0.0.1)")
 	flag.IntVar(&serverPort, "port", 8888, "设置服务器端口(默认是8888)")
+	flag.StringVar(&langTag, "lang", "", "设置界面语言 zh-CN/en-US(默认读取LANG环境变量)")
+	flag.StringVar(&transport, "transport", "tcp", "设置传输协议 tcp/kcp/unix，和服务器的配置保持一致(默认是tcp)")
+	flag.StringVar(&socketPath, "socket", "/tmp/chat.sock", "设置Unix socket的路径，-transport unix 时使用")
 }
 
 type Client struct {
@@ -27,6 +36,8 @@ type Client struct {
 	flag         int          // 当前client的模式
 	responseChan chan string  // 用于接收server消息的channel
 	done         chan struct{} // 用于通知程序退出的通道
+	history      *messageHistory // 最近收到的带ID的消息
+	lastTyping   time.Time       // 上一次发送"正在输入"通知的时间
 }
 
 func NewClient(serverIp string, serverPort int) *Client {
@@ -37,28 +48,60 @@ func NewClient(serverIp string, serverPort int) *Client {
 		flag        : 999,
 		responseChan: make(chan string),
 		done        : make(chan struct{}),
+		history     : newMessageHistory(),
 
 	}
 
 	// 连接server
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", client.ServerIp, client.ServerPort))
+	addr := net.JoinHostPort(client.ServerIp, strconv.Itoa(client.ServerPort))
+	if transport == "unix" {
+		addr = socketPath
+	}
+	conn, err := dial(transport, addr)
 	if err != nil {
-		fmt.Println("net.Dial err:", err)
+		fmt.Println(T(MsgDialError), err)
 		return nil
 	}
 
 	client.conn = conn
 
+	// 和server协商当前连接使用的语言，KCP的服务器也要收到这第一条数据才会接受连接
+	_, err = conn.Write([]byte("lang|" + string(locale) + "\n"))
+	if err != nil {
+		fmt.Println(T(MsgWriteError), err)
+		conn.Close()
+		return nil
+	}
+
 	// 返回对象	
 	return client
 }
 
+// 按照传输协议连接server，kcp是基于UDP的可靠传输，丢包严重的网络上延迟更低
+// unix只能连接本机的服务器，服务器可以按照当前用户的uid自动认证
+func dial(network, addr string) (net.Conn, error) {
+	if network != "kcp" {
+		return net.Dial(network, addr)
+	}
+
+	sess, err := kcp.DialWithOptions(addr, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	// 和服务器使用同样的快速模式参数
+	sess.SetStreamMode(true)
+	sess.SetNoDelay(1, 10, 2, 1)
+	sess.SetACKNoDelay(true)
+	sess.SetWindowSize(128, 128)
+	return sess, nil
+}
+
 func (c *Client) menu() bool {
-	fmt.Println(">>>>>> 1. 公聊模式")
-	fmt.Println(">>>>>> 2. 私聊模式")
-	fmt.Println(">>>>>> 3. 更新用户名")
-	fmt.Println(">>>>>> 4. 查询在线用户")
-	fmt.Println(">>>>>> 0. 退出")
+	fmt.Println(T(MsgMenuPublic))
+	fmt.Println(T(MsgMenuPrivate))
+	fmt.Println(T(MsgMenuRename))
+	fmt.Println(T(MsgMenuWho))
+	fmt.Println(T(MsgMenuExit))
 
 	/*
 	var flag int
@@ -75,17 +118,16 @@ func (c *Client) menu() bool {
 	*/
 
 	// 优化：可以处理字符串输入
-	reader := bufio.NewReader(os.Stdin) // 从标准输入读取内容
-	input, err := reader.ReadString('\n') // 读取直到遇到\n
+	input, err := stdin.ReadLine(nil) // 读取直到遇到\n
 	if err != nil {
-		fmt.Println("reader.ReadString err:", err)
+		fmt.Println(T(MsgReadError), err)
 		return false
 	}
 
 	input = strings.TrimSpace(input) // 去掉input两端的空格
 	flag, err := strconv.Atoi(input) // 将input转换成int类型
 	if err != nil {
-		fmt.Println(">>>>>>请输入合法范围内的数字")
+		fmt.Println(T(MsgMenuInvalid))
 		return false
 	}
 
@@ -93,17 +135,16 @@ func (c *Client) menu() bool {
 		c.flag = flag
 		return true
 	} else {
-		fmt.Println(">>>>>>请输入合法范围内的数字")
+		fmt.Println(T(MsgMenuInvalid))
 		return false
 	}
 }
 
 func (c *Client) UpdateName() bool {
-	fmt.Println(">>>>>> 请输入用户名:")
-	reader := bufio.NewReader(os.Stdin)  // 从标准输入读取内容
-	name, err := reader.ReadString('\n')  // 读取直到遇到\n
+	fmt.Println(T(MsgAskName))
+	name, err := stdin.ReadLine(nil)  // 读取直到遇到\n
 	if err != nil {
-		fmt.Println("reader.ReadString err:", err)
+		fmt.Println(T(MsgReadError), err)
 		return false
 	}
 
@@ -111,7 +152,7 @@ func (c *Client) UpdateName() bool {
 	sendMsg := "rename|" + c.Name + "\n"
 	_, err = c.conn.Write([]byte(sendMsg)) // 将sendMsg发送给服务器
 	if err != nil {
-		fmt.Println("conn.Write err:", err)
+		fmt.Println(T(MsgWriteError), err)
 		return false
 	}
 
@@ -120,14 +161,13 @@ func (c *Client) UpdateName() bool {
 
 // 公聊模式
 func (c *Client) PublicChat() {
-	fmt.Println(">>>>>> 请输入聊天内容，exit退出")
-	reader := bufio.NewReader(os.Stdin) // 从标准输入读取内容
+	fmt.Println(T(MsgAskPublic))
 
 	for {
-		fmt.Println("公聊>>>")
-		chatMsg, err := reader.ReadString('\n') // 读取直到遇到\n
+		fmt.Println(T(MsgPromptPublic))
+		chatMsg, err := stdin.ReadLine(c.typing("")) // 读取直到遇到\n，输入时通知其他用户
 		if err != nil {
-			fmt.Println("reader.ReadString err:", err)
+			fmt.Println(T(MsgReadError), err)
 			return
 		}
 
@@ -138,41 +178,60 @@ func (c *Client) PublicChat() {
 
 		if len(chatMsg) != 0 {
 			sendMsg := chatMsg + "\n"
+			if cmd, ok := chatCommand(chatMsg); ok {
+				sendMsg = cmd
+			}
 			_, err := c.conn.Write([]byte(sendMsg))
 			if err != nil {
-				fmt.Println("conn.Write err:", err)
+				fmt.Println(T(MsgWriteError), err)
 				break
 			}
 		}
 	}
 }
 
+// 返回输入时调用的函数：通知服务器当前用户"正在输入"，to为私聊对象，公聊时为空
+// 按照typingInterval限流，持续输入时也只是偶尔发送
+func (c *Client) typing(to string) func() {
+	return func() {
+		if time.Since(c.lastTyping) < typingInterval {
+			return
+		}
+		c.lastTyping = time.Now()
+
+		sendMsg := "typing\n"
+		if to != "" {
+			sendMsg = "typing|" + to + "\n"
+		}
+		c.conn.Write([]byte(sendMsg))
+	}
+}
+
 // 查询在线用户
 func (c *Client) SelectUsers() {
 	sendMsg := "who\n"
 	_, err := c.conn.Write([]byte(sendMsg))
 	if err != nil {
-		fmt.Println("conn.Write err:", err)
+		fmt.Println(T(MsgWriteError), err)
 		return
 	}
 }
 
 // 私聊模式
 func (c *Client) PrivateChat() {
-	reader := bufio.NewReader(os.Stdin) // 从标准输入读取内容
 	// var remoteName string
 	var chatMsg string
 
 	c.SelectUsers()
 
-	fmt.Println(">>>>>> 请输入聊天对象用户名，exit退出:")
+	fmt.Println(T(MsgAskRemote))
 	// fmt.Scanln(&remoteName)
 
 	for {
-		fmt.Print("私聊对象>>>")
-		remoteName, err := reader.ReadString('\n') // 读取直到遇到\n
+		fmt.Print(T(MsgPromptRemote))
+		remoteName, err := stdin.ReadLine(nil) // 读取直到遇到\n
 		if err != nil {
-			fmt.Println("reader.ReadString err", err)
+			fmt.Println(T(MsgReadError), err)
 			return
 		}
 
@@ -181,12 +240,12 @@ func (c *Client) PrivateChat() {
 			break
 		}
 
-		fmt.Println(">>>>>> 请输入消息内容，exit退出:")
+		fmt.Println(T(MsgAskPrivate))
 		for {
-			fmt.Print("私聊内容>>>")
-			chatMsg, err = reader.ReadString('\n') // 读取直到遇到\n
+			fmt.Print(T(MsgPromptPrivate))
+			chatMsg, err = stdin.ReadLine(c.typing(remoteName)) // 读取直到遇到\n，输入时只通知私聊对象
 			if err != nil {
-				fmt.Println("读取消息内容失败:", err)
+				fmt.Println(T(MsgReadPrivateError), err)
 				return
 			}
 			chatMsg = strings.TrimSpace(chatMsg) // 去掉chatMsg两端的空格
@@ -196,16 +255,19 @@ func (c *Client) PrivateChat() {
 
 			if len(chatMsg) != 0 {
 				sendMsg := "to|" + remoteName + "|" + chatMsg + "\n"
+				if cmd, ok := chatCommand(chatMsg); ok {
+					sendMsg = cmd
+				}
 				_, err := c.conn.Write([]byte(sendMsg))
 				if err != nil {
-					fmt.Println("conn.Write err:", err)
+					fmt.Println(T(MsgWriteError), err)
 					break
 				}
 			}
 		}
 
 		c.SelectUsers()
-		fmt.Println(">>>>>> 请输入聊天对象用户名，exit退出:")
+		fmt.Println(T(MsgAskRemote))
 
 	}
 
@@ -219,45 +281,54 @@ func (c *Client) Run() {
 		switch c.flag { // switch会自动break，如果需要继续执行下一个case，需要使用fallthrough，但是fallthrough仅仅会转到下一个case
 		case 1:
 			// 公聊模式
-			fmt.Println(">>>>>> 公聊模式")
+			fmt.Println(T(MsgModePublic))
 			c.PublicChat()
 
 		case 2:
 			// 私聊模式
-			fmt.Println(">>>>>> 私聊模式")
+			fmt.Println(T(MsgModePrivate))
 			c.PrivateChat()
 
 		case 3:
 			// 更新用户名
-			fmt.Println(">>>>>> 更新用户名")
+			fmt.Println(T(MsgModeRename))
 			c.UpdateName()
 		case 4:
 			// 查询在线用户
-			fmt.Println(">>>>>> 查询在线用户")
+			fmt.Println(T(MsgModeWho))
 			c.SelectUsers()
 		}
 	}
 
 	// 用户选择退出，通知其他goroutine，关闭连接
-	fmt.Println(">>>>>> 正在退出......")
+	fmt.Println(T(MsgExiting))
+	stdin.stop() // 恢复终端的设置
 	close(c.done) // 关闭done通道
 	c.conn.Close()
 }
 
 // 这段逻辑不能写到Run()中，如果写到Run()中，那么Run()就会阻塞在这里，无法继续执行
 func (c *Client) DealResponse() {
-	// 一旦client.conn有数据，就直接拷贝到os.Stdout标准输出上，永久阻塞监听
-	_, err := io.Copy(os.Stdout, c.conn)
-	// 等价于：
-	// for {
-	// 	buf := make([]byte, 4096)
-	// 	n, err := c.conn.Read(buf)
-	// 	fmt.Println(buf[:n])
+	// 一旦client.conn有数据，就按行读取并显示到标准输出上，永久阻塞监听
+	// 不能直接 io.Copy(os.Stdout, c.conn)，因为修改、删除、正在输入这些控制消息需要转换后再显示
+	reader := bufio.NewReader(c.conn)
+	var err error
+	for {
+		var line string
+		line, err = reader.ReadString('\n')
+		if len(line) > 0 {
+			c.render(strings.TrimRight(line, "\r\n"))
+		}
+		if err != nil {
+			break
+		}
+	}
 
-	if err != nil {
-		fmt.Println("\n>>>>>> 与服务器的连接已断开，客户端即将退出...")
+	if err != io.EOF {
+		fmt.Println(T(MsgDisconnected))
 	}
 	// 无论是正常EOF还是错误导致的退出，都结束客户端
+	stdin.stop()
 	os.Exit(0)
 }
 
@@ -266,16 +337,25 @@ func main() {
 	// 命令行解析
 	flag.Parse()
 
+	// 选择界面语言：命令行参数优先，其次是环境变量
+	locale = detectLocale()
+	if l, ok := ParseLocale(langTag); ok {
+		locale = l
+	}
+
 	client := NewClient(serverIp, serverPort)
 	if client == nil {
-		fmt.Println(">>>>>> 连接服务器失败")
+		fmt.Println(T(MsgConnectFailed))
 		return
 	}
 
 	// 单独开启一个goroutine处理server的回执消息
 	go client.DealResponse()
 
-	fmt.Println(">>>>>> 连接服务器成功")
+	fmt.Println(T(MsgConnected))
+
+	// 逐字符读取用户的输入，开始输入时就可以通知"正在输入"
+	stdin.start()
 
 	client.Run()
 }
\ No newline at end of file
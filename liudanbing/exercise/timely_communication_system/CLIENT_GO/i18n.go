@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Locale 表示客户端界面使用的语言
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN"
+	EnUS Locale = "en-US"
+)
+
+// MsgKey 是消息目录中的一条消息的标识
+type MsgKey string
+
+const (
+	MsgMenuPublic  MsgKey = "menu.public"
+	MsgMenuPrivate MsgKey = "menu.private"
+	MsgMenuRename  MsgKey = "menu.rename"
+	MsgMenuWho     MsgKey = "menu.who"
+	MsgMenuExit    MsgKey = "menu.exit"
+	MsgMenuInvalid MsgKey = "menu.invalid"
+
+	MsgModePublic  MsgKey = "mode.public"
+	MsgModePrivate MsgKey = "mode.private"
+	MsgModeRename  MsgKey = "mode.rename"
+	MsgModeWho     MsgKey = "mode.who"
+
+	MsgAskName          MsgKey = "ask.name"
+	MsgAskPublic        MsgKey = "ask.public"
+	MsgPromptPublic     MsgKey = "prompt.public"
+	MsgAskRemote        MsgKey = "ask.remote"
+	MsgPromptRemote     MsgKey = "prompt.remote"
+	MsgAskPrivate       MsgKey = "ask.private"
+	MsgPromptPrivate    MsgKey = "prompt.private"
+	MsgReadPrivateError MsgKey = "error.read_private"
+
+	MsgReadError  MsgKey = "error.read"
+	MsgWriteError MsgKey = "error.write"
+	MsgDialError  MsgKey = "error.dial"
+
+	MsgConnected     MsgKey = "conn.ok"
+	MsgConnectFailed MsgKey = "conn.failed"
+	MsgDisconnected  MsgKey = "conn.lost"
+	MsgExiting       MsgKey = "conn.exiting"
+
+	MsgTyping     MsgKey = "chat.typing"
+	MsgEdited     MsgKey = "chat.edited"
+	MsgDeleted    MsgKey = "chat.deleted"
+	MsgDeletedWas MsgKey = "chat.deleted_was"
+	MsgMention    MsgKey = "chat.mention"
+	MsgKeyword    MsgKey = "chat.keyword"
+)
+
+var catalogs = map[Locale]map[MsgKey]string{
+	ZhCN: {
+		MsgMenuPublic:  ">>>>>> 1. 公聊模式",
+		MsgMenuPrivate: ">>>>>> 2. 私聊模式",
+		MsgMenuRename:  ">>>>>> 3. 更新用户名",
+		MsgMenuWho:     ">>>>>> 4. 查询在线用户",
+		MsgMenuExit:    ">>>>>> 0. 退出",
+		MsgMenuInvalid: ">>>>>>请输入合法范围内的数字",
+
+		MsgModePublic:  ">>>>>> 公聊模式",
+		MsgModePrivate: ">>>>>> 私聊模式",
+		MsgModeRename:  ">>>>>> 更新用户名",
+		MsgModeWho:     ">>>>>> 查询在线用户",
+
+		MsgAskName:          ">>>>>> 请输入用户名:",
+		MsgAskPublic:        ">>>>>> 请输入聊天内容，exit退出，/edit 消息ID 内容 修改，/delete 消息ID 删除",
+		MsgPromptPublic:     "公聊>>>",
+		MsgAskRemote:        ">>>>>> 请输入聊天对象用户名，exit退出:",
+		MsgPromptRemote:     "私聊对象>>>",
+		MsgAskPrivate:       ">>>>>> 请输入消息内容，exit退出，/edit 消息ID 内容 修改，/delete 消息ID 删除:",
+		MsgPromptPrivate:    "私聊内容>>>",
+		MsgReadPrivateError: "读取消息内容失败:",
+
+		MsgReadError:  "读取输入失败:",
+		MsgWriteError: "发送消息失败:",
+		MsgDialError:  "连接服务器出错:",
+
+		MsgConnected:     ">>>>>> 连接服务器成功",
+		MsgConnectFailed: ">>>>>> 连接服务器失败",
+		MsgDisconnected:  "\n>>>>>> 与服务器的连接已断开，客户端即将退出...",
+		MsgExiting:       ">>>>>> 正在退出......",
+
+		MsgTyping:     "... %s 正在输入",
+		MsgEdited:     "#%s %s (已编辑)",
+		MsgDeleted:    "#%s (已删除)",
+		MsgDeletedWas: "#%s (已删除) 原内容：%s",
+		MsgMention:    "[有人@你] #%s %s",
+		MsgKeyword:    "[关键字 %[2]s] #%[1]s %[3]s",
+	},
+	EnUS: {
+		MsgMenuPublic:  ">>>>>> 1. Public chat",
+		MsgMenuPrivate: ">>>>>> 2. Private chat",
+		MsgMenuRename:  ">>>>>> 3. Change user name",
+		MsgMenuWho:     ">>>>>> 4. List online users",
+		MsgMenuExit:    ">>>>>> 0. Exit",
+		MsgMenuInvalid: ">>>>>>Please enter a number in the valid range",
+
+		MsgModePublic:  ">>>>>> Public chat",
+		MsgModePrivate: ">>>>>> Private chat",
+		MsgModeRename:  ">>>>>> Change user name",
+		MsgModeWho:     ">>>>>> List online users",
+
+		MsgAskName:          ">>>>>> Please enter a user name:",
+		MsgAskPublic:        ">>>>>> Please enter a message, exit to leave, /edit ID text to edit, /delete ID to delete",
+		MsgPromptPublic:     "public>>>",
+		MsgAskRemote:        ">>>>>> Please enter the user to chat with, exit to leave:",
+		MsgPromptRemote:     "to>>>",
+		MsgAskPrivate:       ">>>>>> Please enter a message, exit to leave, /edit ID text to edit, /delete ID to delete:",
+		MsgPromptPrivate:    "message>>>",
+		MsgReadPrivateError: "Failed to read the message:",
+
+		MsgReadError:  "Failed to read input:",
+		MsgWriteError: "Failed to send message:",
+		MsgDialError:  "Failed to connect to server:",
+
+		MsgConnected:     ">>>>>> Connected to server",
+		MsgConnectFailed: ">>>>>> Could not connect to server",
+		MsgDisconnected:  "\n>>>>>> Connection to server lost, exiting...",
+		MsgExiting:       ">>>>>> Exiting......",
+
+		MsgTyping:     "... %s is typing",
+		MsgEdited:     "#%s %s (edited)",
+		MsgDeleted:    "#%s (deleted)",
+		MsgDeletedWas: "#%s (deleted) was: %s",
+		MsgMention:    "[mentioned you] #%s %s",
+		MsgKeyword:    "[keyword %[2]s] #%[1]s %[3]s",
+	},
+}
+
+// 当前客户端使用的语言，由命令行参数或者环境变量决定
+var locale = ZhCN
+
+// ParseLocale 将 zh、zh_CN.UTF-8、en-US 这类写法转换成Locale
+func ParseLocale(s string) (Locale, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.ToLower(strings.ReplaceAll(s, "_", "-"))
+
+	switch {
+	case s == "zh" || strings.HasPrefix(s, "zh-"):
+		return ZhCN, true
+	case s == "en" || strings.HasPrefix(s, "en-"):
+		return EnUS, true
+	}
+	return "", false
+}
+
+// 从环境变量中检测语言，检测不到时使用中文
+func detectLocale() Locale {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if l, ok := ParseLocale(os.Getenv(env)); ok {
+			return l
+		}
+	}
+	return ZhCN
+}
+
+// T 按照当前语言翻译消息
+func T(key MsgKey, args ...any) string {
+	format, ok := catalogs[locale][key]
+	if !ok {
+		format, ok = catalogs[ZhCN][key]
+	}
+	if !ok {
+		return string(key)
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
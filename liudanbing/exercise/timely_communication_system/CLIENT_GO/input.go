@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 两次"正在输入"通知的最小间隔，和服务器的限流间隔一致
+const typingInterval = 3 * time.Second
+
+// lineReader 从标准输入按行读取用户的输入
+// 标准输入是终端时关闭终端的行缓冲，逐个字符读取并自己回显，
+// 这样用户开始输入时(而不是按下回车之后)就可以通知服务器"正在输入"
+type lineReader struct {
+	in  *bufio.Reader
+	raw bool // 是否已经关闭了终端的行缓冲
+}
+
+// 所有读取标准输入的地方共用一个lineReader，避免多个bufio.Reader各自缓存一部分输入
+var stdin = &lineReader{in: bufio.NewReader(os.Stdin)}
+
+// 尽量切换到逐字符读取，不是终端或者不支持时仍然按行读取
+func (r *lineReader) start() {
+	r.raw = enableRawInput()
+}
+
+// 恢复终端原来的设置，退出前调用
+func (r *lineReader) stop() {
+	if r.raw {
+		restoreInput()
+		r.raw = false
+	}
+}
+
+// ReadLine 读取一行，返回的内容和 ReadString('\n') 一样包括末尾的\n
+// 每输入一个字符调用一次onTyping，onTyping为nil时不通知
+func (r *lineReader) ReadLine(onTyping func()) (string, error) {
+	if !r.raw {
+		return r.in.ReadString('\n')
+	}
+
+	var line []rune
+	for {
+		ch, _, err := r.in.ReadRune()
+		if err != nil {
+			return string(line), err
+		}
+
+		switch {
+		case ch == '\n' || ch == '\r':
+			fmt.Print("\n")
+			return string(line) + "\n", nil
+
+		case ch == 0x7f || ch == '\b': // 退格，删除最后一个字符
+			if len(line) == 0 {
+				continue
+			}
+			w := runeWidth(line[len(line)-1])
+			line = line[:len(line)-1]
+			fmt.Print(strings.Repeat("\b", w) + strings.Repeat(" ", w) + strings.Repeat("\b", w))
+
+		case ch == 0x04: // Ctrl+D，空行时和按行读取一样返回EOF
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+
+		case unicode.IsControl(ch): // 忽略方向键等其它控制字符
+
+		default:
+			line = append(line, ch)
+			fmt.Print(string(ch))
+			if onTyping != nil {
+				onTyping()
+			}
+		}
+	}
+}
+
+// 字符在终端中占的列数，中日韩文字和全角符号占两列
+func runeWidth(ch rune) int {
+	if unicode.In(ch, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) ||
+		(ch >= 0x3000 && ch <= 0x303f) || (ch >= 0xff01 && ch <= 0xff60) {
+		return 2
+	}
+	return 1
+}
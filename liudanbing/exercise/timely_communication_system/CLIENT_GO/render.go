@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 客户端最多记住多少条带ID的消息，用于显示修改和删除
+const historyLimit = 200
+
+// 终端的高亮显示和响铃
+const (
+	highlightStart = "\033[1;33m"
+	highlightEnd   = "\033[0m"
+	bell           = "\a"
+)
+
+func highlight(s string) string {
+	return highlightStart + s + highlightEnd
+}
+
+// 记录服务器发来的带ID的消息，key: 消息ID
+type messageHistory struct {
+	lines map[string]string
+	order []string
+}
+
+func newMessageHistory() *messageHistory {
+	return &messageHistory{lines: make(map[string]string)}
+}
+
+func (h *messageHistory) put(id, line string) {
+	if _, ok := h.lines[id]; !ok {
+		h.order = append(h.order, id)
+	}
+	h.lines[id] = line
+
+	if len(h.order) > historyLimit {
+		delete(h.lines, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// 处理服务器发来的一行消息，控制消息(typing/edit/delete)转换成可读的文本
+func (c *Client) render(line string) {
+	switch {
+	case strings.HasPrefix(line, "typing|"):
+		fmt.Println(T(MsgTyping, line[7:]))
+
+	case strings.HasPrefix(line, "edit|"):
+		// 格式：edit|消息ID|修改后的消息
+		parts := strings.SplitN(line[5:], "|", 2)
+		if len(parts) != 2 {
+			fmt.Println(line)
+			return
+		}
+		c.history.put(parts[0], parts[1])
+		fmt.Println(T(MsgEdited, parts[0], parts[1]))
+
+	case strings.HasPrefix(line, "delete|"):
+		// 格式：delete|消息ID
+		id := line[7:]
+		if old, ok := c.history.lines[id]; ok {
+			fmt.Println(T(MsgDeletedWas, id, old))
+		} else {
+			fmt.Println(T(MsgDeleted, id))
+		}
+
+	case strings.HasPrefix(line, "mention|"):
+		// 格式：mention|消息ID|消息，有人@了自己
+		parts := strings.SplitN(line[8:], "|", 2)
+		if len(parts) != 2 {
+			fmt.Println(line)
+			return
+		}
+		fmt.Println(bell + highlight(T(MsgMention, parts[0], parts[1])))
+
+	case strings.HasPrefix(line, "keyword|"):
+		// 格式：keyword|消息ID|关键字|消息，消息中包含自己设置的关键字
+		parts := strings.SplitN(line[8:], "|", 3)
+		if len(parts) != 3 {
+			fmt.Println(line)
+			return
+		}
+		text := strings.ReplaceAll(parts[2], parts[1], highlight(parts[1]))
+		fmt.Println(bell + T(MsgKeyword, parts[0], highlight(parts[1]), text))
+
+	case strings.HasPrefix(line, "#"):
+		// 格式：#消息ID 消息内容
+		if i := strings.IndexByte(line, ' '); i > 1 {
+			c.history.put(line[1:i], line[i+1:])
+		}
+		fmt.Println(line)
+
+	default:
+		fmt.Println(line)
+	}
+}
+
+// 聊天模式下的修改、删除命令：/edit 消息ID 新的内容，/delete 消息ID
+// 返回需要发给服务器的消息，不是命令时返回false
+func chatCommand(chatMsg string) (string, bool) {
+	fields := strings.SplitN(chatMsg, " ", 3)
+	switch {
+	case fields[0] == "/edit" && len(fields) == 3:
+		return "edit|" + strings.TrimPrefix(fields[1], "#") + "|" + fields[2] + "\n", true
+	case fields[0] == "/delete" && len(fields) == 2:
+		return "delete|" + strings.TrimPrefix(fields[1], "#") + "\n", true
+	}
+	return "", false
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// 进入逐字符模式之前的终端设置
+var savedTermios *unix.Termios
+
+// 关闭标准输入的行缓冲(ICANON)和回显(ECHO)，由lineReader自己回显
+// Ctrl+C仍然会产生信号，收到信号时先恢复终端再退出
+func enableRawInput() bool {
+	fd := int(os.Stdin.Fd())
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil { // 不是终端，例如输入来自管道
+		return false
+	}
+
+	saved := *termios
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return false
+	}
+	savedTermios = &saved
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		restoreInput()
+		os.Exit(1)
+	}()
+	return true
+}
+
+func restoreInput() {
+	if savedTermios != nil {
+		unix.IoctlSetTermios(int(os.Stdin.Fd()), unix.TCSETS, savedTermios)
+	}
+}
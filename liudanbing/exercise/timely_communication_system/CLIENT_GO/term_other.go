@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+// 其它系统上仍然按行读取，不通知"正在输入"
+func enableRawInput() bool {
+	return false
+}
+
+func restoreInput() {}
@@ -0,0 +1,15 @@
+package bots
+
+import (
+	"SERVER_GO/session"
+	"fmt"
+)
+
+// New 按名字创建机器人，配置文件中的 features.bots 通过这里查找
+func New(name string) (session.Bot, error) {
+	switch name {
+	case "calc":
+		return NewCalcBot(name), nil
+	}
+	return nil, fmt.Errorf("unknown bot %q", name)
+}
@@ -0,0 +1,153 @@
+package bots
+
+import (
+	"SERVER_GO/i18n"
+	"SERVER_GO/session"
+	"commandLineCalculator/calculator"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CalcBot 是一个计算器机器人，例如 to|calc|1+2*3 或者公聊 @calc (1+2)*3
+type CalcBot struct {
+	name string
+}
+
+func NewCalcBot(name string) *CalcBot {
+	return &CalcBot{name: name}
+}
+
+func (b *CalcBot) Name() string {
+	return b.name
+}
+
+func (b *CalcBot) OnMessage(self *session.User, msg session.BotMessage) {
+	result, err := Eval(msg.Text)
+	if err != nil {
+		// 错误按照发送者的语言翻译
+		self.Reply(msg, msg.Text+" => "+msg.From.T(ErrorKey(err)))
+		return
+	}
+	self.Reply(msg, msg.Text+" = "+strconv.FormatFloat(result, 'g', -1, 64))
+}
+
+var (
+	errSyntax = errors.New("syntax error")
+	errCalc   = errors.New("calculation failed")
+)
+
+// ErrorKey 返回Eval的错误在消息目录中的key
+func ErrorKey(err error) i18n.Key {
+	if errors.Is(err, errCalc) {
+		return i18n.CalcFailed
+	}
+	return i18n.CalcSyntax
+}
+
+// Eval 计算四则运算表达式，支持括号和负号，每一步运算都交给 calculator.Calculate
+func Eval(expr string) (float64, error) {
+	p := &parser{src: strings.TrimSpace(expr)}
+	if p.src == "" {
+		return 0, errSyntax
+	}
+
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.skipSpace(); p.pos != len(p.src) {
+		return 0, errSyntax
+	}
+	return v, nil
+}
+
+// 递归下降解析器
+// expr   = term { ("+" | "-") term }
+// term   = factor { ("*" | "/") factor }
+// factor = number | "(" expr ")" | "-" factor
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// 如果下一个字符是ops中的一个，返回它并前进
+func (p *parser) op(ops string) (string, bool) {
+	p.skipSpace()
+	if p.pos < len(p.src) && strings.IndexByte(ops, p.src[p.pos]) >= 0 {
+		p.pos++
+		return p.src[p.pos-1 : p.pos], true
+	}
+	return "", false
+}
+
+func (p *parser) expr() (float64, error) {
+	return p.binary(calculator.ADD+calculator.SUB, p.term)
+}
+
+func (p *parser) term() (float64, error) {
+	return p.binary(calculator.MUL+calculator.DIV, p.factor)
+}
+
+func (p *parser) binary(ops string, next func() (float64, error)) (float64, error) {
+	a, err := next()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		operator, ok := p.op(ops)
+		if !ok {
+			return a, nil
+		}
+
+		b, err := next()
+		if err != nil {
+			return 0, err
+		}
+
+		a, ok = calculator.Calculate(a, b, operator)
+		if !ok {
+			return 0, errCalc
+		}
+	}
+}
+
+func (p *parser) factor() (float64, error) {
+	if _, ok := p.op(calculator.SUB); ok {
+		v, err := p.factor()
+		return -v, err
+	}
+
+	if _, ok := p.op("("); ok {
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := p.op(")"); !ok {
+			return 0, errSyntax
+		}
+		return v, nil
+	}
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] == '.' || unicode.IsDigit(rune(p.src[p.pos]))) {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, errSyntax
+	}
+	v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return 0, errSyntax
+	}
+	return v, nil
+}
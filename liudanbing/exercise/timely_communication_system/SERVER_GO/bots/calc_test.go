@@ -0,0 +1,61 @@
+package bots
+
+import (
+	"SERVER_GO/i18n"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want float64
+	}{
+		{"1+2*3", 7},
+		{"1 - 2 - 3", -4}, // 左结合
+		{"8 / 4 / 2", 1},  // 左结合
+		{"(1+2)*3", 9},    // 括号
+		{"((2))*(3+(4-1))", 12},
+		{"-3+5", 2}, // 负号
+		{"2*-3", -6},
+		{"--4", 4},
+		{"-(1+2)*2", -6},
+		{" 1.5 * 2 ", 3},
+	} {
+		got, err := Eval(tc.expr)
+		if err != nil || got != tc.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", tc.expr, got, err, tc.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		key  i18n.Key
+	}{
+		{"1/0", i18n.CalcFailed},
+		{"1/(2-2)", i18n.CalcFailed},
+		{"", i18n.CalcSyntax},
+		{"1+", i18n.CalcSyntax},
+		{"(1+2", i18n.CalcSyntax},
+		{"1+2)", i18n.CalcSyntax}, // 多余的字符
+		{"1+2 abc", i18n.CalcSyntax},
+		{"1..2", i18n.CalcSyntax},
+		{"*3", i18n.CalcSyntax},
+	} {
+		got, err := Eval(tc.expr)
+		if err == nil {
+			t.Errorf("Eval(%q) = %v, want an error", tc.expr, got)
+			continue
+		}
+		if key := ErrorKey(err); key != tc.key {
+			t.Errorf("Eval(%q) error %v has key %s, want %s", tc.expr, err, key, tc.key)
+		}
+	}
+
+	// 错误消息按照语言翻译
+	_, err := Eval("1/0")
+	if zh, en := i18n.T(i18n.ZhCN, ErrorKey(err)), i18n.T(i18n.EnUS, ErrorKey(err)); zh == en {
+		t.Errorf("calculation error is not translated: %q", en)
+	}
+}
@@ -0,0 +1,135 @@
+// chatlog 是持久化的聊天记录：服务器把每一条公聊、私聊以及修改和删除追加到一个 JSON Lines 文件中，
+// 服务器的 search| 命令和离线工具 logsearch 都从这个文件查询
+package chatlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// 公聊的房间，目前服务器只有这一个房间
+const PublicRoom = "public"
+
+// 记录的类型
+const (
+	KindMessage = ""       // 一条新消息
+	KindEdit    = "edit"   // 修改之前的消息，Text为新的内容
+	KindDelete  = "delete" // 删除之前的消息
+)
+
+// ErrDisabled 表示服务器没有配置聊天记录文件
+var ErrDisabled = errors.New("chat log is disabled")
+
+// Entry 是聊天记录文件中的一行
+type Entry struct {
+	Kind string    `json:"kind,omitempty"`
+	ID   uint64    `json:"id"` // 服务器分配的消息ID，服务器重启后重新编号
+	Time time.Time `json:"time"`
+	Room string    `json:"room,omitempty"` // 公聊的房间，私聊时为空
+	From string    `json:"from,omitempty"`
+	To   string    `json:"to,omitempty"` // 私聊对象
+	Text string    `json:"text,omitempty"`
+}
+
+// 是否是私聊消息
+func (e Entry) Private() bool {
+	return e.To != ""
+}
+
+// Writer 把记录追加到聊天记录文件，可以被多个goroutine同时使用
+type Writer struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// Open 打开(或创建)聊天记录文件，新的记录追加到文件末尾
+func Open(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{file: f}, nil
+}
+
+// Append 追加一条记录，每条记录一次写入，服务器崩溃时最多丢失正在写的一行
+func (w *Writer) Append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err = w.file.Write(data)
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.file.Close()
+}
+
+// Read 读取全部记录，并把修改和删除合并到对应的消息上，返回按时间顺序排列的消息
+// 服务器重启后消息ID会重复，修改和删除总是作用在它之前最近的一条同ID消息上
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	latest := make(map[uint64]int) // 消息ID -> entries中的下标
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch e.Kind {
+		case KindMessage:
+			latest[e.ID] = len(entries)
+			entries = append(entries, e)
+		case KindEdit:
+			if i, ok := latest[e.ID]; ok {
+				entries[i].Text = e.Text
+			}
+		case KindDelete:
+			if i, ok := latest[e.ID]; ok {
+				entries[i].Kind = KindDelete
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// 去掉已经删除的消息
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Kind != KindDelete {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
+
+// ReadFile 读取聊天记录文件，文件不存在时返回空的记录
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
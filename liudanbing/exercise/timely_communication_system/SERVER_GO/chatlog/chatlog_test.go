@@ -0,0 +1,144 @@
+package chatlog_test
+
+import (
+	"SERVER_GO/chatlog"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)
+
+func writeLog(t *testing.T, entries ...chatlog.Entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "chat.jsonl")
+	w, err := chatlog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, e := range entries {
+		if err := w.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestReadAppliesEditsAndDeletes(t *testing.T) {
+	path := writeLog(t,
+		chatlog.Entry{ID: 1, Time: t0, Room: chatlog.PublicRoom, From: "alice", Text: "helo"},
+		chatlog.Entry{ID: 2, Time: t0, Room: chatlog.PublicRoom, From: "bob", Text: "oops"},
+		chatlog.Entry{Kind: chatlog.KindEdit, ID: 1, Time: t0, Text: "hello"},
+		chatlog.Entry{Kind: chatlog.KindDelete, ID: 2, Time: t0},
+		// 服务器重启后ID重新从1开始，之后的修改只作用于新的消息
+		chatlog.Entry{ID: 1, Time: t0.Add(time.Hour), From: "carol", To: "alice", Text: "hi"},
+		chatlog.Entry{Kind: chatlog.KindEdit, ID: 1, Time: t0.Add(time.Hour), Text: "hi!"},
+	)
+
+	entries, err := chatlog.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.From+":"+e.Text)
+	}
+	if want := "alice:hello carol:hi!"; strings.Join(got, " ") != want {
+		t.Fatalf("entries = %v, want %s", got, want)
+	}
+
+	if entries, err := chatlog.ReadFile(filepath.Join(t.TempDir(), "missing")); err != nil || entries != nil {
+		t.Fatalf("missing file: %v, %v", entries, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	entries := []chatlog.Entry{
+		{ID: 1, Time: t0, Room: chatlog.PublicRoom, From: "alice", Text: "Hello all"},
+		{ID: 2, Time: t0.Add(time.Hour), From: "alice", To: "bob", Text: "hello bob"},
+		{ID: 3, Time: t0.AddDate(0, 0, 1), Room: chatlog.PublicRoom, From: "bob", Text: "hello tomorrow"},
+		{ID: 4, Time: t0.AddDate(0, 0, 1), From: "bob", To: "carol", Text: "hello carol"},
+	}
+	ids := func(q chatlog.Query) string {
+		var s []string
+		for _, e := range chatlog.Search(entries, q) {
+			s = append(s, string(rune('0'+e.ID)))
+		}
+		return strings.Join(s, ",")
+	}
+
+	day, _ := chatlog.ParseTime("2024-01-02", true)
+	for _, tc := range []struct {
+		name string
+		q    chatlog.Query
+		want string
+	}{
+		{"keyword", chatlog.Query{Keyword: "HELLO"}, "1,2,3,4"},
+		{"from", chatlog.Query{From: "bob"}, "3,4"},
+		{"with", chatlog.Query{With: "bob"}, "2,4"},
+		{"room", chatlog.Query{Room: chatlog.PublicRoom}, "1,3"},
+		{"until date", chatlog.Query{Until: day}, "1,2"},
+		{"since", chatlog.Query{Since: t0.Add(time.Minute)}, "2,3,4"},
+		{"viewer", chatlog.Query{Viewer: "carol"}, "1,3,4"},
+		{"viewer with", chatlog.Query{Viewer: "alice", With: "carol"}, ""},
+		{"public only", chatlog.Query{Public: true, Keyword: "hello"}, "1,3"},
+		{"public only with", chatlog.Query{Public: true, With: "bob"}, ""},
+		{"limit keeps latest", chatlog.Query{Limit: 2}, "3,4"},
+	} {
+		if got := ids(tc.q); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	var q chatlog.Query
+	if err := q.Set("since", "yesterday"); err == nil {
+		t.Error("bad time accepted")
+	}
+	if err := q.Set("color", "red"); err == nil {
+		t.Error("unknown filter accepted")
+	}
+}
+
+func TestExport(t *testing.T) {
+	entries := []chatlog.Entry{
+		{ID: 1, Time: t0, Room: chatlog.PublicRoom, From: "alice", Text: "a *bold*, claim"},
+		{ID: 2, Time: t0, From: "alice", To: "bob", Text: "psst"},
+	}
+
+	var md bytes.Buffer
+	if err := chatlog.Export(&md, "md", entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Chat transcript", `**alice**: a \*bold\*, claim`, "**alice** → **bob**: psst"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := chatlog.Export(&js, "json", entries); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []chatlog.Entry
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded) != 2 || decoded[1].To != "bob" {
+		t.Errorf("json round trip: %v, %+v", err, decoded)
+	}
+
+	var cs bytes.Buffer
+	if err := chatlog.Export(&cs, "csv", entries); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&cs).ReadAll()
+	if err != nil || len(rows) != 3 || rows[1][5] != "a *bold*, claim" {
+		t.Errorf("csv: %v, %q", err, rows)
+	}
+
+	if err := chatlog.Export(&cs, "pdf", entries); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
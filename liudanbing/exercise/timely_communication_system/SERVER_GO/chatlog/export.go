@@ -0,0 +1,71 @@
+package chatlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持导出的格式
+var Formats = []string{"md", "json", "csv"}
+
+// Export 把消息按照format(md、json、csv)写到w
+func Export(w io.Writer, format string, entries []Entry) error {
+	switch format {
+	case "md", "markdown":
+		return writeMarkdown(w, entries)
+	case "json":
+		return writeJSON(w, entries)
+	case "csv":
+		return writeCSV(w, entries)
+	}
+	return fmt.Errorf("unknown format %q, available: %s", format, strings.Join(Formats, ", "))
+}
+
+func writeMarkdown(w io.Writer, entries []Entry) error {
+	var b strings.Builder
+	b.WriteString("# Chat transcript\n\n")
+	for _, e := range entries {
+		who := "**" + escapeMarkdown(e.From) + "**"
+		if e.Private() {
+			who += " → **" + escapeMarkdown(e.To) + "**"
+		} else if e.Room != "" && e.Room != PublicRoom {
+			who += " (#" + escapeMarkdown(e.Room) + ")"
+		}
+		fmt.Fprintf(&b, "- `%s` %s: %s\n", e.Time.Local().Format(time.DateTime), who, escapeMarkdown(e.Text))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// 转义会改变Markdown格式的字符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;", "\n", " ",
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func writeJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func writeCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "time", "room", "from", "to", "text"})
+	for _, e := range entries {
+		cw.Write([]string{strconv.FormatUint(e.ID, 10), e.Time.Format(time.RFC3339), e.Room, e.From, e.To, e.Text})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,108 @@
+package chatlog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Query 是查询聊天记录的条件，为空的条件不限制
+type Query struct {
+	Keyword string    // 消息内容包含的关键字，不区分大小写
+	From    string    // 发送者
+	With    string    // 私聊对象：只查询和这个用户之间的私聊
+	Room    string    // 房间，例如 public；指定房间时不包括私聊
+	Since   time.Time // 不早于这个时间
+	Until   time.Time // 早于这个时间
+	Viewer  string    // 查询者，不为空时只能查到公聊和查询者参与的私聊
+	Public  bool      // 只查询公聊，用于无法确认身份的查询者
+	Limit   int       // 最多返回多少条，保留最近的
+}
+
+// Match 判断一条消息是否符合条件
+func (q Query) Match(e Entry) bool {
+	if q.Public && e.Private() {
+		return false
+	}
+	if q.Viewer != "" && e.Private() && e.From != q.Viewer && e.To != q.Viewer {
+		return false
+	}
+	if q.Keyword != "" && !strings.Contains(strings.ToLower(e.Text), strings.ToLower(q.Keyword)) {
+		return false
+	}
+	if q.From != "" && e.From != q.From {
+		return false
+	}
+	if q.With != "" {
+		if !e.Private() || (e.From != q.With && e.To != q.With) {
+			return false
+		}
+		if q.Viewer != "" && e.From != q.Viewer && e.To != q.Viewer {
+			return false
+		}
+	}
+	if q.Room != "" && e.Room != q.Room {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Search 返回符合条件的消息，超过Limit时保留最近的
+func Search(entries []Entry, q Query) []Entry {
+	var found []Entry
+	for _, e := range entries {
+		if q.Match(e) {
+			found = append(found, e)
+		}
+	}
+	if q.Limit > 0 && len(found) > q.Limit {
+		found = found[len(found)-q.Limit:]
+	}
+	return found
+}
+
+// 支持的时间格式，都按本地时间解析
+var timeLayouts = []string{time.RFC3339, time.DateTime, "2006-01-02 15:04", time.DateOnly}
+
+// ParseTime 解析查询条件中的时间，例如 2024-01-02、2024-01-02 15:04
+// end为true时(用于until)只有日期的时间表示这一天的结束，这样 until=2024-01-02 包括这一天
+func ParseTime(s string, end bool) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		if end && layout == time.DateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use 2006-01-02 or 2006-01-02 15:04", s)
+}
+
+// Set 按照 key=value 设置一个条件，服务器的 search| 命令和命令行工具共用，
+// key 为 from、with、room、since、until
+func (q *Query) Set(key, value string) error {
+	var err error
+	switch key {
+	case "from":
+		q.From = value
+	case "with":
+		q.With = value
+	case "room":
+		q.Room = value
+	case "since":
+		q.Since, err = ParseTime(value, false)
+	case "until":
+		q.Until, err = ParseTime(value, true)
+	default:
+		err = fmt.Errorf("unknown filter %q", key)
+	}
+	return err
+}
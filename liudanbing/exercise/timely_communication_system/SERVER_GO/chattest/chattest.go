@@ -0,0 +1,289 @@
+// chattest 提供聊天服务器的测试工具：在随机端口上启动一个 core.Server，
+// 并提供可以按脚本发送消息、等待回复的虚拟客户端
+package chattest
+
+import (
+	"SERVER_GO/config"
+	"SERVER_GO/core"
+	"SERVER_GO/i18n"
+	"SERVER_GO/transport"
+	"bufio"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 等待一条消息的默认超时时间
+const DefaultTimeout = 2 * time.Second
+
+// Server 是运行在随机端口上的测试服务器，测试结束时自动关闭
+type Server struct {
+	*core.Server
+	Addr string
+
+	t         testing.TB
+	listener  transport.Listener
+	dial      func() (net.Conn, error) // 客户端连接服务器的方式
+	exactAddr bool                     // 客户端的本地地址是否就是服务器看到的地址
+}
+
+// NewServer 在 127.0.0.1 的随机端口上启动使用默认配置的服务器
+// opts 在服务器启动前执行，可以用来修改超时时间等配置
+func NewServer(t testing.TB, opts ...func(*core.Server)) *Server {
+	t.Helper()
+	return NewServerConfig(t, config.Default(), opts...)
+}
+
+// NewServerConfig 和 NewServer 一样，但是使用cfg创建服务器，用于只在创建时生效的配置，例如聊天记录文件
+// cfg中的监听地址会被忽略
+func NewServerConfig(t testing.TB, cfg config.Config, opts ...func(*core.Server)) *Server {
+	t.Helper()
+
+	cfg.Listen.Transport = transport.TCP
+	s := listen(t, cfg)
+	s.dial = func() (net.Conn, error) { return net.Dial("tcp", s.Addr) }
+	s.exactAddr = true
+	return s.serve(opts)
+}
+
+// NewKCPServer 在 127.0.0.1 的随机端口上启动KCP服务器
+// loss大于0时客户端通过一个按loss的概率随机丢包的UDP代理连接服务器，模拟丢包严重的网络
+func NewKCPServer(t testing.TB, loss float64, opts ...func(*core.Server)) *Server {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Listen.Transport = transport.KCP
+	s := listen(t, cfg)
+	addr := s.Addr
+	if loss > 0 {
+		addr = LossyUDPProxy(t, s.Addr, loss)
+	}
+	// 客户端连接后立即发送语言(见DialLocale)，服务器收到第一个数据包才会Accept
+	s.dial = func() (net.Conn, error) { return transport.DialKCP(addr) }
+	return s.serve(opts)
+}
+
+func listen(t testing.TB, cfg config.Config) *Server {
+	t.Helper()
+
+	listener, err := transport.Listen(cfg.Listen.Transport, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("transport.Listen: %v", err)
+	}
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	cfg.Listen.IP = host
+	cfg.Listen.Port, _ = strconv.Atoi(port)
+	return &Server{
+		Server:   core.New(cfg),
+		Addr:     listener.Addr().String(),
+		t:        t,
+		listener: listener,
+	}
+}
+
+func (s *Server) serve(opts []func(*core.Server)) *Server {
+	for _, opt := range opts {
+		opt(s.Server)
+	}
+
+	go s.Serve(s.listener)
+	s.t.Cleanup(func() { s.listener.Close() })
+
+	return s
+}
+
+// ServeUnix 让同一个服务器同时在一个临时的Unix socket上提供服务，
+// 返回的Server连接的是这个Unix socket，和原来的Server共用同一个core.Server
+func (s *Server) ServeUnix() *Server {
+	s.t.Helper()
+
+	path := filepath.Join(s.t.TempDir(), "chat.sock")
+	listener, err := transport.ListenUnix(path)
+	if err != nil {
+		s.t.Fatalf("transport.ListenUnix: %v", err)
+	}
+
+	unix := &Server{
+		Server:   s.Server,
+		Addr:     path,
+		t:        s.t,
+		listener: listener,
+		dial:     func() (net.Conn, error) { return net.Dial("unix", path) },
+	}
+	go unix.Serve(listener)
+	s.t.Cleanup(func() { listener.Close() })
+
+	return unix
+}
+
+// Dial 连接一个新的虚拟客户端，并等待它的上线消息
+func (s *Server) Dial() *Client {
+	s.t.Helper()
+
+	c := s.DialRaw()
+	if s.exactAddr {
+		c.Expect("[" + c.Addr + "]" + c.Addr + ":")
+		return c
+	}
+
+	// 经过代理或者UDP连接时，服务器看到的地址和本地地址不同，从自己的上线消息中取出服务器分配的用户名
+	// 测试中的客户端是依次连接的，收到的第一条上线消息就是自己的
+	line := c.Expect(i18n.T(i18n.Default, i18n.UserOnline))
+	if end := strings.Index(line, "]"); strings.HasPrefix(line, "[") && end > 0 {
+		c.Addr = line[1:end]
+	}
+	return c
+}
+
+// DialRaw 连接一个新的虚拟客户端，不等待任何消息，例如用来测试被服务器拒绝的连接
+// 和真实的客户端一样，连接后立即发送 lang| 协商默认语言
+func (s *Server) DialRaw() *Client {
+	s.t.Helper()
+	return s.DialLocale(i18n.Default)
+}
+
+// DialLocale 和 DialRaw 一样，但是协商的语言是l
+func (s *Server) DialLocale(l i18n.Locale) *Client {
+	s.t.Helper()
+
+	conn, err := s.dial()
+	if err != nil {
+		s.t.Fatalf("dial %s: %v", s.Addr, err)
+	}
+
+	c := &Client{
+		Addr:  conn.LocalAddr().String(),
+		t:     s.t,
+		conn:  conn,
+		lines: make(chan string, 256),
+	}
+	go c.read()
+	s.t.Cleanup(func() { conn.Close() })
+	c.Send("lang|" + string(l))
+
+	return c
+}
+
+// Client 是一个按脚本收发消息的虚拟客户端
+type Client struct {
+	Addr string // 客户端的本地地址，也是服务器分配的默认用户名
+
+	t     testing.TB
+	conn  net.Conn
+	lines chan string // 服务器发来的消息，连接断开时关闭
+}
+
+func (c *Client) read() {
+	defer close(c.lines)
+
+	reader := bufio.NewReader(c.conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimRight(line, "\n"); line != "" {
+			c.lines <- line
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Send 发送一行消息，自动加上 \n
+func (c *Client) Send(msg string) {
+	c.t.Helper()
+
+	if _, err := c.conn.Write([]byte(msg + "\n")); err != nil {
+		c.t.Fatalf("%s send %q: %v", c.Addr, msg, err)
+	}
+}
+
+// Expect 等待一条包含substr的消息并返回它，中间收到的其它消息会被跳过
+func (c *Client) Expect(substr string) string {
+	c.t.Helper()
+
+	timeout := time.After(DefaultTimeout)
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				c.t.Fatalf("%s: connection closed while waiting for %q", c.Addr, substr)
+			}
+			if strings.Contains(line, substr) {
+				return line
+			}
+		case <-timeout:
+			c.t.Fatalf("%s: timed out waiting for %q", c.Addr, substr)
+		}
+	}
+}
+
+// ExpectNone 在d时间内不应该收到包含substr的消息
+func (c *Client) ExpectNone(substr string, d time.Duration) {
+	c.t.Helper()
+
+	timeout := time.After(d)
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return
+			}
+			if strings.Contains(line, substr) {
+				c.t.Fatalf("%s: unexpected message %q", c.Addr, line)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// Collect 收集d时间内收到的全部消息
+func (c *Client) Collect(d time.Duration) []string {
+	lines := make([]string, 0)
+	timeout := time.After(d)
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return lines
+			}
+			lines = append(lines, line)
+		case <-timeout:
+			return lines
+		}
+	}
+}
+
+// ExpectClosed 等待服务器关闭连接
+func (c *Client) ExpectClosed(d time.Duration) {
+	c.t.Helper()
+
+	timeout := time.After(d)
+	for {
+		select {
+		case _, ok := <-c.lines:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			c.t.Fatalf("%s: connection still open after %s", c.Addr, d)
+		}
+	}
+}
+
+// Rename 修改用户名并等待服务器确认
+func (c *Client) Rename(name string) {
+	c.t.Helper()
+
+	c.Send("rename|" + name)
+	c.Expect(i18n.T(i18n.Default, i18n.RenameDone, name))
+}
+
+// Close 断开连接
+func (c *Client) Close() {
+	c.conn.Close()
+}
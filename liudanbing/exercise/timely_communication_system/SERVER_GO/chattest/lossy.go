@@ -0,0 +1,79 @@
+package chattest
+
+import (
+	"math/rand/v2"
+	"net"
+	"sync"
+	"testing"
+)
+
+// LossyUDPProxy 在 127.0.0.1 的随机端口上把UDP数据包转发到target，两个方向都按loss的概率随机丢包，返回代理的地址
+// 每个客户端使用一个单独的上游socket，服务器仍然可以区分不同的客户端
+func LossyUDPProxy(t testing.TB, target string, loss float64) string {
+	t.Helper()
+
+	front, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("net.ListenUDP: %v", err)
+	}
+	targetAddr, err := net.ResolveUDPAddr("udp", target)
+	if err != nil {
+		t.Fatalf("net.ResolveUDPAddr: %v", err)
+	}
+
+	var lock sync.Mutex
+	upstreams := make(map[string]*net.UDPConn) // key: 客户端地址
+	t.Cleanup(func() {
+		front.Close()
+		lock.Lock()
+		defer lock.Unlock()
+		for _, up := range upstreams {
+			up.Close()
+		}
+	})
+
+	drop := func() bool { return rand.Float64() < loss }
+
+	// 服务器 -> 客户端
+	relayBack := func(up *net.UDPConn, client *net.UDPAddr) {
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := up.Read(buf)
+			if err != nil {
+				return
+			}
+			if !drop() {
+				front.WriteToUDP(buf[:n], client)
+			}
+		}
+	}
+
+	// 客户端 -> 服务器
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, client, err := front.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			lock.Lock()
+			up, ok := upstreams[client.String()]
+			if !ok {
+				if up, err = net.DialUDP("udp", nil, targetAddr); err != nil {
+					lock.Unlock()
+					continue
+				}
+				upstreams[client.String()] = up
+				go relayBack(up, client)
+			}
+			lock.Unlock()
+
+			if !drop() {
+				up.Write(buf[:n])
+			}
+		}
+	}()
+
+	return front.LocalAddr().String()
+}
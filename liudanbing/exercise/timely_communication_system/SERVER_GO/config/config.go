@@ -0,0 +1,173 @@
+// config 是聊天服务器的配置，原来 SERVER_GO 和 SERVER_GO_ERROR 两个版本之间的差异
+// (超时时间、保留的用户名等)都通过配置文件来选择
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Listen    Listen   `yaml:"listen"`
+	Endpoints []Listen `yaml:"endpoints"` // 同时监听的其它端点，例如Unix socket，修改后需要重启
+	Timeouts  Timeouts `yaml:"timeouts"`
+	Limits    Limits   `yaml:"limits"`
+	Features  Features `yaml:"features"`
+	Filters   Filters  `yaml:"filters"`
+	UnixAuth  UnixAuth `yaml:"unix_auth"`
+
+	ChatLog string `yaml:"chat_log"` // 聊天记录文件(JSON Lines)，为空时不保存，修改后需要重启
+
+	MOTD   string   `yaml:"motd"`   // 每日消息，用户上线时显示
+	Admins []string `yaml:"admins"` // 管理员的用户名，只对经过认证的身份生效(见UnixAuth)
+}
+
+// 监听的地址
+type Listen struct {
+	IP        string `yaml:"ip"`
+	Port      int    `yaml:"port"`
+	Transport string `yaml:"transport"` // tcp、kcp(基于UDP的可靠传输)或者unix，为空时使用tcp
+	Path      string `yaml:"path"`      // Unix socket 的路径，只有unix使用
+}
+
+// Address 返回监听的地址，unix为socket的路径，其它为 ip:port
+func (l Listen) Address() string {
+	if l.Transport == "unix" {
+		return l.Path
+	}
+	return net.JoinHostPort(l.IP, strconv.Itoa(l.Port))
+}
+
+func (l Listen) validate() error {
+	switch l.Transport {
+	case "", "tcp", "kcp":
+		if l.Port < 0 || l.Port > 65535 {
+			return fmt.Errorf("invalid listen port %d", l.Port)
+		}
+	case "unix":
+		if l.Path == "" {
+			return errors.New("unix endpoint needs a path")
+		}
+	default:
+		return fmt.Errorf("unknown transport %q, use tcp, kcp or unix", l.Transport)
+	}
+	return nil
+}
+
+// Unix socket 连接按照对端进程的uid自动认证，不需要再改名
+type UnixAuth struct {
+	Enabled bool           `yaml:"enabled"`
+	Users   map[int]string `yaml:"users"` // uid -> 用户名，没有配置的uid使用系统的用户名
+}
+
+// 不活跃的超时时间，0表示关闭该功能(YAML中写成 0s)
+type Timeouts struct {
+	Away time.Duration `yaml:"away"` // 多久不活跃后自动设置为离开
+	Kick time.Duration `yaml:"kick"` // 多久不活跃后被强踢，SERVER_GO为120s，SERVER_GO_ERROR为5s
+}
+
+// 资源限制，0表示不限制
+type Limits struct {
+	MaxUsers      int `yaml:"max_users"`       // 最多同时在线的用户数，不包括机器人
+	MaxLineLength int `yaml:"max_line_length"` // 一条消息最多的字节数
+}
+
+// 可以开关的功能
+type Features struct {
+	ReservedNames []string `yaml:"reserved_names"` // 不允许使用的用户名，SERVER_GO为[exit]，SERVER_GO_ERROR没有限制
+	Bots          []string `yaml:"bots"`           // 启动时注册的机器人，例如 calc
+}
+
+// 消息内容过滤，默认全部关闭
+type Filters struct {
+	MaxLength  int      `yaml:"max_length"`  // 一条消息最多的字符数，0表示不限制
+	Blocklist  []string `yaml:"blocklist"`   // 屏蔽词，替换成*
+	StripLinks bool     `yaml:"strip_links"` // 去掉消息中的链接
+	Spam       Spam     `yaml:"spam"`
+}
+
+// 重复消息检测：Window时间内第Repeat次发送相同的消息时拒绝，Repeat为0表示关闭
+type Spam struct {
+	Repeat int           `yaml:"repeat"`
+	Window time.Duration `yaml:"window"`
+}
+
+// Default 返回默认配置，和原来的 SERVER_GO 行为一致
+func Default() Config {
+	return Config{
+		Listen: Listen{
+			IP:        "127.0.0.1",
+			Port:      8888,
+			Transport: "tcp",
+		},
+		Timeouts: Timeouts{
+			Away: 60 * time.Second,
+			Kick: 120 * time.Second,
+		},
+		Limits: Limits{
+			MaxUsers:      1000,
+			MaxLineLength: 4096,
+		},
+		Features: Features{
+			ReservedNames: []string{"exit"},
+			Bots:          []string{"calc"},
+		},
+	}
+}
+
+// Load 从YAML文件中读取配置，文件中没有写的字段使用默认值
+func Load(filename string) (Config, error) {
+	cfg := Default()
+	if err := cfg.loadFile(filename); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// 用文件中的配置覆盖c
+func (c *Config) loadFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
+
+// AllEndpoints 返回全部监听的端点：listen 和 endpoints
+func (c Config) AllEndpoints() []Listen {
+	return append([]Listen{c.Listen}, c.Endpoints...)
+}
+
+// Validate 检查配置是否合法
+func (c Config) Validate() error {
+	for _, l := range c.AllEndpoints() {
+		if err := l.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Timeouts.Away < 0 || c.Timeouts.Kick < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+	if c.Timeouts.Away > 0 && c.Timeouts.Kick > 0 && c.Timeouts.Away >= c.Timeouts.Kick {
+		return errors.New("away timeout must be shorter than kick timeout")
+	}
+	if c.Limits.MaxUsers < 0 || c.Limits.MaxLineLength < 0 {
+		return errors.New("limits must not be negative")
+	}
+	if c.Filters.MaxLength < 0 {
+		return errors.New("filters.max_length must not be negative")
+	}
+	if spam := c.Filters.Spam; spam.Repeat != 0 && (spam.Repeat < 2 || spam.Window <= 0) {
+		return errors.New("filters.spam needs repeat >= 2 and a positive window")
+	}
+	return nil
+}
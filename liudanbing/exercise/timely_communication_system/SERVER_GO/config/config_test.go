@@ -0,0 +1,115 @@
+package config_test
+
+import (
+	"SERVER_GO/config"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "server.yaml")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// 文件中没有写的字段保持默认值
+func TestLoadOverlaysDefaults(t *testing.T) {
+	cfg, err := config.Load(writeFile(t, "listen:\n  port: 9999\ntimeouts:\n  away: 0s\n  kick: 5s\nfeatures:\n  reserved_names: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	def := config.Default()
+	if cfg.Listen.IP != def.Listen.IP || cfg.Listen.Port != 9999 {
+		t.Errorf("listen = %+v", cfg.Listen)
+	}
+	if cfg.Timeouts.Away != 0 || cfg.Timeouts.Kick != 5*time.Second {
+		t.Errorf("timeouts = %+v", cfg.Timeouts)
+	}
+	if len(cfg.Features.ReservedNames) != 0 {
+		t.Errorf("reserved names = %v", cfg.Features.ReservedNames)
+	}
+	if !slices.Equal(cfg.Features.Bots, def.Features.Bots) {
+		t.Errorf("bots = %v", cfg.Features.Bots)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, content := range []string{
+		"listen:\n  port: 70000\n",
+		"timeouts:\n  away: 10s\n  kick: 5s\n",
+		"timeouts:\n  kick: -1s\n",
+		"listen: [\n",
+		"listen:\n  transport: sctp\n",
+		"endpoints:\n  - transport: unix\n",
+	} {
+		if _, err := config.Load(writeFile(t, content)); err == nil {
+			t.Errorf("Load(%q) succeeded", content)
+		}
+	}
+}
+
+// 后面的层覆盖前面的：默认值 -> 配置文件 -> 环境变量 -> 命令行参数
+func TestLoaderPrecedence(t *testing.T) {
+	filename := writeFile(t, "listen:\n  port: 9001\ntimeouts:\n  away: 30s\nmotd: from file\nadmins: [root]\n")
+	t.Setenv("CHAT_PORT", "9002")
+	t.Setenv("CHAT_MOTD", "from env")
+	t.Setenv("CHAT_ADMINS", "alice, bob")
+
+	loader := config.NewLoader()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader.RegisterFlags(fs)
+	if err := fs.Parse([]string{"-config", filename, "-port", "9003"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Listen.Port != 9003 {
+		t.Errorf("port = %d, want flag value", cfg.Listen.Port)
+	}
+	if cfg.Timeouts.Away != 30*time.Second {
+		t.Errorf("away = %s, want file value", cfg.Timeouts.Away)
+	}
+	if cfg.MOTD != "from env" || !slices.Equal(cfg.Admins, []string{"alice", "bob"}) {
+		t.Errorf("motd = %q, admins = %v, want env values", cfg.MOTD, cfg.Admins)
+	}
+	if cfg.Timeouts.Kick != config.Default().Timeouts.Kick {
+		t.Errorf("kick = %s, want default", cfg.Timeouts.Kick)
+	}
+
+	// 重新加载时读取修改后的配置文件，命令行参数仍然优先
+	if err := os.WriteFile(filename, []byte("listen:\n  port: 9001\ntimeouts:\n  away: 45s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeouts.Away != 45*time.Second || cfg.Listen.Port != 9003 {
+		t.Errorf("after reload: away = %s, port = %d", cfg.Timeouts.Away, cfg.Listen.Port)
+	}
+}
+
+func TestLoaderBadValues(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	config.NewLoader().RegisterFlags(fs)
+	if err := fs.Parse([]string{"-kick", "soon"}); err == nil {
+		t.Error("bad -kick accepted")
+	}
+
+	t.Setenv("CHAT_MAX_USERS", "many")
+	if _, err := config.NewLoader().Load(); err == nil {
+		t.Error("bad CHAT_MAX_USERS accepted")
+	}
+}
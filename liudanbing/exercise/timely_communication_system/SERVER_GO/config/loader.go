@@ -0,0 +1,150 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 环境变量的前缀，例如 -max-users 对应 CHAT_MAX_USERS
+const EnvPrefix = "CHAT_"
+
+// option 是一项可以通过环境变量和命令行参数设置的配置
+type option struct {
+	name  string // 命令行参数名
+	usage string
+	set   func(c *Config, v string) error
+}
+
+// 环境变量名
+func (o option) env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(o.name, "-", "_"))
+}
+
+var options = []option{
+	{"ip", "监听的IP", func(c *Config, v string) error {
+		c.Listen.IP = v
+		return nil
+	}},
+	{"port", "监听的端口", intOption(func(c *Config) *int { return &c.Listen.Port })},
+	{"transport", "传输协议：tcp 或者 kcp", func(c *Config, v string) error {
+		c.Listen.Transport = v
+		return nil
+	}},
+	{"away", "多久不活跃后自动设置为离开，例如 60s，0s表示关闭", durationOption(func(c *Config) *time.Duration { return &c.Timeouts.Away })},
+	{"kick", "多久不活跃后被强踢，例如 120s，0s表示关闭", durationOption(func(c *Config) *time.Duration { return &c.Timeouts.Kick })},
+	{"max-users", "最多同时在线的用户数，0表示不限制", intOption(func(c *Config) *int { return &c.Limits.MaxUsers })},
+	{"max-line-length", "一条消息最多的字节数，0表示不限制", intOption(func(c *Config) *int { return &c.Limits.MaxLineLength })},
+	{"unix", "同时监听的Unix socket路径", func(c *Config, v string) error {
+		c.Endpoints = append(c.Endpoints, Listen{Transport: "unix", Path: v})
+		return nil
+	}},
+	{"unix-auth", "Unix socket连接按照对端uid自动认证(true/false)", func(c *Config, v string) error {
+		enabled, err := strconv.ParseBool(v)
+		c.UnixAuth.Enabled = enabled
+		return err
+	}},
+	{"chat-log", "聊天记录文件，为空时不保存", func(c *Config, v string) error {
+		c.ChatLog = v
+		return nil
+	}},
+	{"motd", "每日消息", func(c *Config, v string) error {
+		c.MOTD = v
+		return nil
+	}},
+	{"admins", "管理员的用户名，用逗号分隔，只对unix_auth认证的连接生效", listOption(func(c *Config) *[]string { return &c.Admins })},
+	{"reserved-names", "不允许使用的用户名，用逗号分隔", listOption(func(c *Config) *[]string { return &c.Features.ReservedNames })},
+	{"bots", "启动时注册的机器人，用逗号分隔", listOption(func(c *Config) *[]string { return &c.Features.Bots })},
+}
+
+func intOption(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func durationOption(field func(c *Config) *time.Duration) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// 逗号分隔的列表，空字符串表示空列表
+func listOption(field func(c *Config) *[]string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		list := []string{}
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		*field(c) = list
+		return nil
+	}
+}
+
+// Loader 按照 默认值 -> 配置文件 -> 环境变量 -> 命令行参数 的顺序加载配置，后面的覆盖前面的
+type Loader struct {
+	File string // 配置文件，为空时不读取
+
+	flags map[string]string // 命令行中设置过的参数
+}
+
+func NewLoader() *Loader {
+	return &Loader{flags: make(map[string]string)}
+}
+
+// RegisterFlags 在fs上注册 -config 以及每一项配置对应的命令行参数
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&l.File, "config", l.File, "配置文件(YAML)，不指定时不读取")
+	for _, o := range options {
+		fs.Func(o.name, o.usage+" (环境变量 "+o.env()+")", func(v string) error {
+			// 解析时先检查一次格式，错误可以和其它命令行错误一起提示
+			if err := o.set(&Config{}, v); err != nil {
+				return err
+			}
+			l.flags[o.name] = v
+			return nil
+		})
+	}
+}
+
+// Load 加载配置，收到SIGHUP时再次调用，重新读取配置文件和环境变量，命令行参数仍然优先
+func (l *Loader) Load() (Config, error) {
+	cfg := Default()
+
+	if l.File != "" {
+		if err := cfg.loadFile(l.File); err != nil {
+			return cfg, err
+		}
+	}
+	for _, o := range options {
+		if v, ok := os.LookupEnv(o.env()); ok {
+			if err := o.set(&cfg, v); err != nil {
+				return cfg, fmt.Errorf("%s: %w", o.env(), err)
+			}
+		}
+	}
+	for _, o := range options {
+		if v, ok := l.flags[o.name]; ok {
+			if err := o.set(&cfg, v); err != nil {
+				return cfg, fmt.Errorf("-%s: %w", o.name, err)
+			}
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,487 @@
+// core 是聊天服务器的核心：接受连接、管理用户的生命周期、广播消息
+// 它依赖 transport 的连接接口和 session 的用户实现，并为 session 实现 session.Hub 接口
+package core
+
+import (
+	"SERVER_GO/chatlog"
+	"SERVER_GO/config"
+	"SERVER_GO/filter"
+	"SERVER_GO/i18n"
+	"SERVER_GO/session"
+	"SERVER_GO/transport"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Server struct {
+	Ip string
+	Port int
+
+	  // 在线用户的列表，取代了原来的 OnlineMap + MapLock
+	users *session.Registry
+
+	  // 消息广播的channel
+	Message chan ChatMessage
+	history *session.History // 最近的消息，用于修改和删除
+	mailbox *session.Mailbox // 离线消息
+	chatLog string           // 聊天记录文件，为空时没有开启
+
+	listenOnce sync.Once // 保证只启动一个广播的goroutine
+
+	cfg     atomic.Pointer[config.Config] // 当前的配置，Reload时整体替换
+	filters atomic.Pointer[filter.Chain]  // 消息内容的过滤链，Reload时重新创建
+	bots atomic.Int32                  // 机器人的数量，不计入在线人数限制
+}
+
+// 编译时检查Server实现了session.Hub
+var _ session.Hub = (*Server)(nil)
+
+// 广播的消息，发送给每个用户前按照该用户的语言进行翻译
+type ChatMessage struct {
+	ID   uint64   // 消息ID，系统消息为0
+	From string   // 发起者，格式为 [addr]name
+	Key  i18n.Key // 系统消息的key，例如上线、下线
+	Text string   // 用户发送的原始内容，Key为空时使用
+
+	Frame  string        // 控制消息(typing/edit/delete)，不为空时原样发送
+	Except *session.User // 不发送给该用户，例如"正在输入"不需要发给自己
+}
+
+// 将消息翻译成指定语言的文本
+func (m ChatMessage) Render(l i18n.Locale) string {
+	if m.Frame != "" {
+		return m.Frame
+	}
+
+	text := m.Text
+	if m.Key != "" {
+		text = i18n.T(l, m.Key)
+	}
+	return session.MsgPrefix(m.ID) + m.From + ":" + text
+}
+
+  // 创建一个server的接口，使用默认配置
+func NewServer(ip string, port int) *Server {
+	cfg := config.Default()
+	cfg.Listen.IP, cfg.Listen.Port = ip, port
+
+	return New(cfg)
+}
+
+  // 按照配置创建一个server
+func New(cfg config.Config) *Server {
+	  // 此时的server是一个指针
+	server := &Server{
+		Ip       : cfg.Listen.IP,
+		Port     : cfg.Listen.Port,
+		users    : session.NewRegistry(),
+		Message  : make(chan ChatMessage),
+		history  : session.NewHistory(),
+		mailbox  : session.NewMailbox(),
+	}
+	server.cfg.Store(&cfg)
+	server.storeFilters(cfg.Filters)
+
+	  // 打开聊天记录文件，失败时不保存聊天记录
+	if cfg.ChatLog != "" {
+		w, err := chatlog.Open(cfg.ChatLog)
+		if err != nil {
+			fmt.Println("chatlog.Open err:", err)
+		} else {
+			server.history.SetLog(w)
+			server.chatLog = cfg.ChatLog
+		}
+	}
+
+	return server
+}
+
+  // 当前的配置
+func (s *Server) Config() config.Config {
+	return *s.cfg.Load()
+}
+
+  // 应用新的配置，超时时间、限制、MOTD、管理员和保留的用户名立即生效
+  // 监听地址、机器人和聊天记录文件只在启动时使用，修改后需要重启
+func (s *Server) Reload(cfg config.Config) {
+	old := s.cfg.Load()
+	if cfg.Listen != old.Listen || !slices.Equal(cfg.Endpoints, old.Endpoints) ||
+		!slices.Equal(cfg.Features.Bots, old.Features.Bots) || cfg.ChatLog != old.ChatLog {
+		fmt.Println("监听地址、机器人和聊天记录文件的修改需要重启服务器才能生效")
+	}
+	cfg.Listen = old.Listen
+	cfg.Endpoints = old.Endpoints
+	cfg.Features.Bots = old.Features.Bots
+	cfg.ChatLog = old.ChatLog
+
+	s.cfg.Store(&cfg)
+	s.storeFilters(cfg.Filters)
+}
+
+func (s *Server) storeFilters(c config.Filters) {
+	chain := filter.New(c)
+	s.filters.Store(&chain)
+}
+
+  // 内容过滤，用户发送的公聊、私聊和修改后的消息都要经过过滤链
+func (s *Server) Filter(msg filter.Message) (string, error) {
+	return s.filters.Load().Apply(msg)
+}
+
+  // 启动服务器的接口，是Server的方法, S大写表示public
+  // 同时监听配置中的全部端点(TCP、KCP、Unix socket)，任何一个端点监听失败时都不启动
+func (s *Server) Start() {
+	var listeners []transport.Listener
+	  // close listen socket
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+
+	  // socket listen
+	for _, ep := range s.Config().AllEndpoints() {
+		listener, err := transport.Listen(ep.Transport, ep.Address())
+		if       err  != nil {
+			fmt.Println("net.Listen err:", err)
+			return
+		}
+		listeners = append(listeners, listener)
+	}
+
+	var wg sync.WaitGroup
+	for _, listener := range listeners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Serve(listener)
+		}()
+	}
+	wg.Wait()
+}
+
+  // 在已经创建好的listener上提供服务，测试和压测时可以用随机端口的listener
+  // 可以同时在多个listener上调用，listener被关闭后返回
+func (s *Server) Serve(listener transport.Listener) {
+	  // 启动监听Message的goroutine，多个listener共用一个
+	s.listenOnce.Do(func() { go s.ListenMessage() })
+
+	for {
+		                                // accept
+		conn, err := listener.Accept()  // 当accept成功，代表有一个客户端连接进来，conn是和客户端通信的接口
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			fmt.Println("listener.Accept err:", err)
+			continue
+		}
+
+		  // do handler
+		go s.Handler(conn)
+	}
+}
+
+func (s *Server) Handler(conn transport.Conn) {
+	  // 当前连接的业务
+	  // fmt.Println("连接建立成功")
+
+	  // 在线人数已满，此时还不知道客户端的语言，使用默认语言
+	if max := s.Config().Limits.MaxUsers; max > 0 && s.users.Len()-int(s.bots.Load()) >= max {
+		conn.Write([]byte(i18n.T(i18n.Default, i18n.ServerFull, max) + "\n"))
+		conn.Close()
+		return
+	}
+
+	  // 创建一个用户
+	user := session.NewUser(conn, s)
+
+	/*v3 -> v4
+	  // 用户上线了，将用户加入到OnlineMap中
+	s.mapLock.Lock()
+	s.OnlineMap[user.Name] = user
+	s.mapLock.Unlock()
+	  // 广播当前用户上线消息
+	s.BroadCast(user, "已上线")
+	*/
+	// 客户端连接后先发送的 lang| 在上线之前处理，这样欢迎信息使用客户端的语言
+	reader := bufio.NewReader(conn)
+	negotiateLocale(conn, reader, user, s.Config().Limits.MaxLineLength)
+
+	user.Online() // v4
+
+	// Handler是用户生命周期唯一的负责者：无论是客户端断开还是被强踢，都在这里下线
+	defer user.Offline()
+
+	// 监听用户是否活跃的channel
+	isLive := make(chan bool)
+	// 读取消息的goroutine退出时关闭，代表客户端断开
+	readDone := make(chan struct{})
+
+	// 不活跃的定时器：先自动设置为离开，再强踢
+	awayTimer := newIdleTimer(s.Config().Timeouts.Away)
+	kickTimer := newIdleTimer(s.Config().Timeouts.Kick)
+	defer awayTimer.Stop()
+	defer kickTimer.Stop()
+
+	// 接受客户端发送的消息
+	go func() {
+		defer close(readDone)
+
+		// 按行读取：一次Read可能读到多条消息，也可能只读到半条消息
+		for {
+			max := s.Config().Limits.MaxLineLength
+			line, err := readLine(reader, max)
+			if errors.Is(err, errLineTooLong) {
+				user.SendMessage(user.T(i18n.LineTooLong, max) + "\n")
+				continue
+			}
+			if err != nil { // io.EOF代表客户端断开，net.ErrClosed代表被强踢后关闭了连接
+				if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+					fmt.Println("conn.Read err:", err)
+				}
+				/*v3 -> v4
+				s.BroadCast(user, "下线")  // 广播用户下线消息
+				*/ 
+				return // 由Handler执行下线
+			}
+
+			// 提取用户的消息(去除\n，兼容\r\n)
+			msg := strings.TrimRight(line, "\r\n")
+
+			/*v3 -> v4
+			// 将得到的消息进行广播
+			s.BroadCast(user, msg)
+			*/
+			// 用户针对msg进行处理
+			user.DoMessage(msg)  // v4
+
+			// 用户的任意消息，代表当前用户是活跃的
+			select {
+			case isLive <- true:
+			case <- user.Done(): // Handler已经退出，没有人再接收isLive
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <- isLive:
+			// 当前用户是活跃的，应该重置定时器
+			timeouts := s.Config().Timeouts
+			resetIdleTimer(awayTimer, timeouts.Away)
+			resetIdleTimer(kickTimer, timeouts.Kick)
+			// 如果之前是自动离开，恢复为在线
+			user.MarkActive()
+
+		case <- awayTimer.C:
+			// 一段时间没有活动，自动设置为离开
+			user.MarkAway()
+
+		case <- readDone:
+			// 客户端断开，退出handler，由defer执行下线
+			return
+
+		case <- kickTimer.C:
+			// 已经超时
+			// 将当前的user强制关闭
+
+			user.SendMessage(user.T(i18n.UserKicked) + "\n")
+			// 退出当前的handler，由defer销毁用户的goroutine并关闭连接
+			return // runtime.Goexit()
+		}
+	}
+}
+
+  // 广播消息的方法(arg1: 由哪个用户发起的, arg2: 消息内容)
+func (s *Server) BroadCast(user *session.User, msg string) {
+	id := s.history.Add(user, nil, msg)
+	sandMsg := ChatMessage{ID: id, From: "[" + user.Addr + "]" + user.Name(), Text: msg}
+
+	s.Message <- sandMsg  // 将消息发送到Message channel中
+
+	// 提醒被@到的用户、机器人以及设置了关键字的用户
+	session.NotifyMentions(s, user, id, msg)
+}
+
+  // 广播系统消息的方法，消息内容由每个接收者的语言决定
+func (s *Server) BroadCastKey(user *session.User, key i18n.Key) {
+	s.Message <- ChatMessage{From: "[" + user.Addr + "]" + user.Name(), Key: key}
+}
+
+  // 广播控制消息的方法，except不为空时不发送给该用户
+func (s *Server) BroadCastFrame(frame string, except *session.User) {
+	s.Message <- ChatMessage{Frame: frame, Except: except}
+}
+
+// 监听Message广播消息channel的goroutine，一旦有消息就发送给全部在线的用户
+func (s *Server) ListenMessage() {
+	for {
+		msg := <- s.Message
+
+		  // 将msg发送给全部在线用户，先拷贝在线用户列表，发送时不持有锁
+		for _, cli := range s.users.Snapshot() {
+			if cli == msg.Except {
+				continue
+			}
+
+			select {
+			case cli.C <- msg.Render(cli.Locale()):  // 按用户的语言翻译后发送到用户的channel中
+			case <- cli.Done():  // 用户已经下线
+			}
+		}
+	}
+}
+// 在线用户列表
+func (s *Server) Users() *session.Registry {
+	return s.users
+}
+
+// 最近的消息
+func (s *Server) History() *session.History {
+	return s.history
+}
+
+// Unix socket 连接的自动认证：按照对端进程的uid返回用户名，没有开启时返回false
+func (s *Server) PeerName(cred transport.Credentials) (string, bool) {
+	auth := s.Config().UnixAuth
+	if !auth.Enabled {
+		return "", false
+	}
+	if name, ok := auth.Users[cred.UID]; ok {
+		return name, true
+	}
+	if u, err := user.LookupId(strconv.Itoa(cred.UID)); err == nil {
+		return u.Username, true
+	}
+	return "uid" + strconv.Itoa(cred.UID), true
+}
+
+// 离线消息
+func (s *Server) Mailbox() *session.Mailbox {
+	return s.mailbox
+}
+
+// 查询聊天记录，没有开启聊天记录时返回 chatlog.ErrDisabled
+// 每次查询都重新读取文件，离线工具 logsearch 用的是同样的查询
+func (s *Server) SearchLog(q chatlog.Query) ([]chatlog.Entry, error) {
+	if s.chatLog == "" {
+		return nil, chatlog.ErrDisabled
+	}
+	entries, err := chatlog.ReadFile(s.chatLog)
+	if err != nil {
+		return nil, err
+	}
+	return chatlog.Search(entries, q), nil
+}
+
+// 是否是管理员，name必须是经过认证的身份(User.Identity)，不能直接使用用户名
+func (s *Server) IsAdmin(name string) bool {
+	return slices.Contains(s.Config().Admins, name)
+}
+
+// 每日消息
+func (s *Server) MOTD() string {
+	return s.Config().MOTD
+}
+
+// 修改每日消息，只修改当前运行的配置，重新加载配置时以配置文件为准
+func (s *Server) SetMOTD(motd string) {
+	for {
+		old := s.cfg.Load()
+		cfg := *old
+		cfg.MOTD = motd
+		if s.cfg.CompareAndSwap(old, &cfg) {
+			return
+		}
+	}
+}
+
+// 是否是不允许使用的用户名
+func (s *Server) IsReservedName(name string) bool {
+	return slices.Contains(s.Config().Features.ReservedNames, name)
+}
+
+// 将机器人注册到服务器，机器人以虚拟用户的身份加入在线用户列表
+// 一般在Start之前调用，所以不广播上线消息
+func (s *Server) AddBot(bot session.Bot) *session.User {
+	user := session.NewBotUser(s, bot)
+	s.users.Add(user)
+	s.bots.Add(1)
+	return user
+}
+
+// 超时时间为0时关闭对应的功能，返回的定时器不会触发
+func newIdleTimer(d time.Duration) *time.Timer {
+	t := time.NewTimer(d)
+	if d <= 0 {
+		t.Stop()
+	}
+	return t
+}
+
+func resetIdleTimer(t *time.Timer, d time.Duration) {
+	if d > 0 {
+		t.Reset(d)
+	}
+}
+
+// 上线前等待客户端发送 lang| 的时间，自带的客户端连接后立即发送，
+// 不发送语言的客户端(例如nc)最多晚这么久收到欢迎信息
+const LangWait = 300 * time.Millisecond
+
+// 如果客户端的第一行是 lang|xx，读取它并切换语言；否则不读取任何内容，留给正常的读取流程
+// 连接不支持读超时时不等待，使用默认语言
+func negotiateLocale(conn transport.Conn, reader *bufio.Reader, user *session.User, max int) {
+	d, ok := conn.(interface{ SetReadDeadline(t time.Time) error })
+	if !ok {
+		return
+	}
+	d.SetReadDeadline(time.Now().Add(LangWait))
+	defer d.SetReadDeadline(time.Time{})
+
+	// Peek 不会消耗输入，超时的时候已经读到的内容仍然留在reader中
+	prefix, err := reader.Peek(len("lang|"))
+	if err != nil || string(prefix) != "lang|" {
+		return
+	}
+	line, err := readLine(reader, max)
+	if err != nil {
+		return
+	}
+	user.SwitchLocale(strings.TrimRight(line, "\r\n")[len("lang|"):])
+}
+
+var errLineTooLong = errors.New("line too long")
+
+// 读取一行，max大于0时超过max字节的行被丢弃并返回errLineTooLong，避免客户端发送超长的行占满内存
+func readLine(r *bufio.Reader, max int) (string, error) {
+	var buf []byte
+	tooLong := false
+	for {
+		frag, err := r.ReadSlice('\n')
+		if max > 0 && len(buf)+len(frag) > max+len("\r\n") {
+			tooLong, buf = true, nil
+		} else if !tooLong {
+			buf = append(buf, frag...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == nil && (tooLong || max > 0 && len(strings.TrimRight(string(buf), "\r\n")) > max) {
+			return "", errLineTooLong
+		}
+		return string(buf), err
+	}
+}
@@ -0,0 +1,754 @@
+package core_test
+
+import (
+	"SERVER_GO/chattest"
+	"SERVER_GO/config"
+	"SERVER_GO/core"
+	"SERVER_GO/i18n"
+	"SERVER_GO/session"
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试中服务器的回复都是默认语言
+func zh(key i18n.Key, args ...any) string {
+	return i18n.T(i18n.Default, key, args...)
+}
+
+// 发送了 lang|en-US 的客户端收到的回复
+func en(key i18n.Key, args ...any) string {
+	return i18n.T(i18n.EnUS, key, args...)
+}
+
+func TestOnlineOfflineBroadcast(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+
+	bob := s.Dial()
+	alice.Expect("[" + bob.Addr + "]" + bob.Addr + ":" + zh(i18n.UserOnline))
+
+	bob.Rename("bob")
+	bob.Close()
+	alice.Expect("[" + bob.Addr + "]bob:" + zh(i18n.UserOffline))
+}
+
+func TestPublicMessage(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+
+	alice.Send("hello everyone")
+	line := bob.Expect("hello everyone")
+	if !strings.HasSuffix(line, "["+alice.Addr+"]alice:hello everyone") {
+		t.Fatalf("unexpected broadcast %q", line)
+	}
+	// 发送者自己也会收到广播
+	alice.Expect("alice:hello everyone")
+}
+
+func TestWho(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+
+	bob.Send("who")
+	online := zh(i18n.PresenceAvailable)
+	bob.Expect(zh(i18n.WhoEntry, 1, alice.Addr, "alice", online))
+	bob.Expect(zh(i18n.WhoEntry, 2, bob.Addr, "bob", online))
+}
+
+func TestRenameCollision(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+
+	bob.Send("rename|alice")
+	bob.Expect(zh(i18n.RenameTaken))
+
+	bob.Send("rename|exit")
+	bob.Expect(zh(i18n.RenameReserved))
+
+	bob.Send("rename||x")
+	bob.Expect(zh(i18n.RenameEmpty))
+	bob.Send("rename|  ")
+	bob.Expect(zh(i18n.RenameEmpty))
+
+	// 改名失败后原来的名字仍然可以使用，alice改名后旧名字可以被别人使用
+	alice.Rename("alice2")
+	bob.Rename("alice")
+
+	bob.Send("who")
+	bob.Expect("]alice:")
+	bob.Expect("]alice2:")
+}
+
+func TestPrivateMessage(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+	carol := s.Dial()
+
+	alice.Send("to|bob|hi bob|how are you")
+	bob.Expect(zh(i18n.PrivateFrom, "alice", "hi bob|how are you"))
+	alice.Expect(zh(i18n.PrivateSent, "bob", "hi bob|how are you"))
+	carol.ExpectNone("hi bob", 200*time.Millisecond)
+
+	alice.Send("to|nobody|hi")
+	alice.Expect(zh(i18n.PrivateNoUser))
+
+	alice.Send("to|bob|")
+	alice.Expect(zh(i18n.PrivateEmpty))
+}
+
+func TestIdleKick(t *testing.T) {
+	s := chattest.NewServer(t, func(s *core.Server) {
+		cfg := s.Config()
+		cfg.Timeouts.Away = 100 * time.Millisecond
+		cfg.Timeouts.Kick = 300 * time.Millisecond
+		s.Reload(cfg)
+	})
+	idle := s.Dial()
+	idle.Rename("idle")
+	// watcher晚一点上线，保证它在idle之后才会被踢
+	time.Sleep(150 * time.Millisecond)
+	watcher := s.Dial()
+
+	idle.Expect(zh(i18n.UserKicked))
+	idle.ExpectClosed(time.Second)
+	watcher.Expect("]idle:" + zh(i18n.UserOffline))
+}
+
+func TestMalformedInput(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+
+	// 缺少消息内容的私聊不能让服务器崩溃
+	alice.Send("to|bob")
+	alice.Expect(zh(i18n.PrivateBadFormat))
+	alice.Send("to||hi")
+	alice.Expect(zh(i18n.PrivateBadFormat))
+
+	alice.Send("status|sleeping")
+	alice.Expect(zh(i18n.StatusBadFormat))
+	alice.Send("who|nobody")
+	alice.Expect(zh(i18n.WhoBadFormat))
+	alice.Send("edit|abc|x")
+	alice.Expect(zh(i18n.EditBadFormat))
+	alice.Send("lang|xx")
+	alice.Expect(zh(i18n.LangUnsupported, "xx", "zh-CN, en-US"))
+
+	// 一次写入多条消息、\r\n结尾的消息都按行处理
+	alice.Send("first\r\nsecond")
+	bob.Expect("alice:first")
+	bob.Expect("alice:second")
+
+	// 服务器仍然正常工作
+	alice.Send("to|bob|still alive")
+	bob.Expect(zh(i18n.PrivateFrom, "alice", "still alive"))
+}
+
+func TestConcurrentRename(t *testing.T) {
+	s := chattest.NewServer(t)
+	clients := make([]*chattest.Client, 8)
+	for i := range clients {
+		clients[i] = s.Dial()
+	}
+
+	// 同时改成同一个名字，只能有一个成功
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Send("rename|same")
+		}()
+	}
+	wg.Wait()
+
+	done, taken := 0, 0
+	for _, c := range clients {
+		for _, line := range c.Collect(200 * time.Millisecond) {
+			switch line {
+			case zh(i18n.RenameDone, "same"):
+				done++
+			case zh(i18n.RenameTaken):
+				taken++
+			}
+		}
+	}
+	if done != 1 || taken != len(clients)-1 {
+		t.Fatalf("rename succeeded %d times, rejected %d times", done, taken)
+	}
+	if n := s.Users().Len(); n != len(clients) {
+		t.Fatalf("registry has %d users, want %d", n, len(clients))
+	}
+}
+
+func TestOfflineRemovesUser(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+
+	bob.Close()
+	alice.Expect("]bob:" + zh(i18n.UserOffline))
+	if _, ok := s.Users().Get("bob"); ok {
+		t.Fatal("bob is still online after disconnecting")
+	}
+
+	// 下线后名字可以再次使用
+	carol := s.Dial()
+	carol.Rename("bob")
+}
+
+func TestLimits(t *testing.T) {
+	s := chattest.NewServer(t, func(s *core.Server) {
+		cfg := s.Config()
+		cfg.Limits.MaxUsers = 1
+		cfg.Limits.MaxLineLength = 8
+		s.Reload(cfg)
+	})
+	alice := s.Dial()
+
+	// 超长的行被丢弃，连接仍然可用
+	alice.Send(strings.Repeat("x", 5000))
+	alice.Expect(zh(i18n.LineTooLong, 8))
+	alice.Send("who")
+	alice.Expect("在线")
+
+	full := s.DialRaw()
+	full.Expect(zh(i18n.ServerFull, 1))
+	full.ExpectClosed(time.Second)
+
+	// 重新加载配置后立即生效
+	cfg := s.Config()
+	cfg.Limits.MaxUsers = 2
+	s.Reload(cfg)
+	s.Dial()
+}
+
+func TestWelcome(t *testing.T) {
+	s := chattest.NewServer(t, func(s *core.Server) {
+		s.SetMOTD("hello world")
+	})
+	alice := s.Dial()
+	alice.Rename("alice")
+
+	// 欢迎信息和自己的上线广播没有先后顺序，所以不用Dial
+	bob := s.DialRaw()
+	bob.Expect(zh(i18n.WelcomeMOTD, "hello world"))
+	bob.Expect(zh(i18n.WelcomeOnline, 2, bob.Addr+", alice"))
+	bob.Expect(zh(i18n.WelcomeHelp))
+}
+
+// 连接后先发送 lang|en-US 的客户端收到英文的欢迎信息
+func TestWelcomeLocale(t *testing.T) {
+	s := chattest.NewServer(t, func(s *core.Server) {
+		s.SetMOTD("hello world")
+	})
+	alice := s.Dial()
+	alice.Rename("alice")
+
+	back := s.DialLocale(i18n.EnUS)
+	back.Expect(en(i18n.LangSwitched, i18n.EnUS))
+	back.Expect(en(i18n.WelcomeMOTD, "hello world"))
+	back.Expect(en(i18n.WelcomeOnline, 2, back.Addr+", alice"))
+	back.Expect(en(i18n.WelcomeHelp))
+
+	// 不发送语言的客户端等待LangWait之后收到默认语言的欢迎信息
+	conn, err := net.Dial("tcp", s.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(core.LangWait + chattest.DefaultTimeout))
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("no welcome for a client without lang|: %v", err)
+		}
+		if strings.Contains(line, zh(i18n.WelcomeHelp)) {
+			break
+		}
+	}
+}
+
+func TestOfflineMail(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	cfg := config.Default()
+	cfg.UnixAuth = config.UnixAuth{Enabled: true, Users: map[int]string{os.Getuid(): "bob"}}
+	tcp := chattest.NewServerConfig(t, cfg)
+	unix := tcp.ServeUnix()
+
+	alice := tcp.Dial()
+	alice.Rename("alice")
+	bob := unix.DialRaw()
+	bob.Expect(zh(i18n.AuthDone, "bob", os.Getuid()))
+	bob.Close()
+	alice.Expect("]bob:" + zh(i18n.UserOffline))
+
+	// 没有认证过的名字不能留言，包括别人用过的名字
+	alice.Send("to|nobody|hi")
+	alice.Expect(zh(i18n.PrivateNoUser))
+	carol := tcp.Dial()
+	carol.Rename("carol")
+	carol.Close()
+	alice.Expect("]carol:" + zh(i18n.UserOffline))
+	alice.Send("to|carol|hi")
+	alice.Expect(zh(i18n.PrivateNoUser))
+
+	alice.Send("to|bob|are you there?")
+	alice.Expect(zh(i18n.PrivateStored, "bob"))
+	alice.Send("to|bob|call me")
+	alice.Expect(zh(i18n.PrivateStored, "bob"))
+
+	// 改名成bob的其他用户收不到bob的离线消息
+	mallory := tcp.Dial()
+	mallory.Rename("bob")
+	mallory.ExpectNone(zh(i18n.MailHeader, 2), 200*time.Millisecond)
+	mallory.Close()
+	alice.Expect("]bob:" + zh(i18n.UserOffline))
+
+	// bob认证之后收到离线消息，使用客户端的语言，只收到一次
+	back := unix.DialLocale(i18n.EnUS)
+	back.Expect(en(i18n.AuthDone, "bob", os.Getuid()))
+	back.Expect(en(i18n.MailHeader, 2))
+	back.Expect(strings.TrimPrefix(en(i18n.MailEntry, "", "alice", "are you there?"), "[] "))
+	back.Expect(strings.TrimPrefix(en(i18n.MailEntry, "", "alice", "call me"), "[] "))
+	back.Close()
+	alice.Expect("]bob:" + zh(i18n.UserOffline))
+
+	again := unix.DialRaw()
+	again.Expect(zh(i18n.AuthDone, "bob", os.Getuid()))
+	again.ExpectNone(zh(i18n.MailHeader, 2), 200*time.Millisecond)
+}
+
+// 管理员权限只属于经过认证的身份，改成管理员的名字不能修改每日消息
+func TestMOTDAdmin(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	cfg := config.Default()
+	cfg.Admins = []string{"root"}
+	cfg.UnixAuth = config.UnixAuth{Enabled: true, Users: map[int]string{os.Getuid(): "root"}}
+	tcp := chattest.NewServerConfig(t, cfg)
+	unix := tcp.ServeUnix()
+
+	alice := tcp.Dial()
+	alice.Send("motd")
+	alice.Expect(zh(i18n.WelcomeMOTD, zh(i18n.MOTDEmpty)))
+
+	alice.Send("motd|free pizza")
+	alice.Expect(zh(i18n.MOTDNotAdmin))
+
+	// 用户名没有密码保护，改成管理员的名字也不是管理员
+	alice.Rename("root")
+	alice.Send("motd|free pizza")
+	alice.Expect(zh(i18n.MOTDNotAdmin))
+	alice.Rename("alice")
+
+	root := unix.DialRaw()
+	root.Expect(zh(i18n.AuthDone, "root", os.Getuid()))
+	root.Send("motd|free pizza")
+	root.Expect(zh(i18n.MOTDChanged))
+	if motd := tcp.MOTD(); motd != "free pizza" {
+		t.Fatalf("MOTD = %q", motd)
+	}
+
+	// 改用其它名字之后不再有管理员权限
+	root.Rename("someone")
+	root.Send("motd|")
+	root.Expect(zh(i18n.MOTDNotAdmin))
+
+	bob := tcp.DialRaw()
+	bob.Expect(zh(i18n.WelcomeMOTD, "free pizza"))
+}
+
+func TestFilters(t *testing.T) {
+	s := chattest.NewServer(t, func(s *core.Server) {
+		cfg := s.Config()
+		cfg.Filters = config.Filters{
+			MaxLength:  30,
+			Blocklist:  []string{"darn"},
+			StripLinks: true,
+			Spam:       config.Spam{Repeat: 2, Window: time.Minute},
+		}
+		s.Reload(cfg)
+	})
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+
+	alice.Send("darn, see http://spam.example")
+	bob.Expect("alice:****, see [link]")
+
+	alice.Send(strings.Repeat("长", 31))
+	alice.Expect(zh(i18n.FilterTooLong, 30))
+
+	alice.Send("to|bob|darn")
+	bob.Expect(zh(i18n.PrivateFrom, "alice", "****"))
+
+	// 被拒绝的消息不会发给其它用户
+	alice.Send("hello")
+	bob.Expect("alice:hello")
+	alice.Send("hello")
+	alice.Expect(zh(i18n.FilterSpam))
+	bob.ExpectNone("alice:hello", 200*time.Millisecond)
+	// 改名不会重新计数
+	alice.Rename("alice2")
+	alice.Send("hello")
+	alice.Expect(zh(i18n.FilterSpam))
+	alice.Rename("alice")
+
+	// 修改消息同样经过过滤
+	alice.Send("fine")
+	id := strings.TrimPrefix(strings.Fields(bob.Expect("alice:fine"))[0], "#")
+	alice.Send("edit|" + id + "|darn")
+	if line := bob.Expect("edit|" + id + "|"); !strings.HasSuffix(line, "alice:****") {
+		t.Fatalf("edited message was not filtered: %q", line)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	cfg := config.Default()
+	cfg.ChatLog = filepath.Join(t.TempDir(), "chat.jsonl")
+	s := chattest.NewServerConfig(t, cfg)
+
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+	carol := s.Dial()
+	carol.Rename("carol")
+
+	alice.Send("hello everyone")
+	carol.Expect("alice:hello everyone")
+	alice.Send("to|bob|hello secret")
+	bob.Expect("hello secret")
+	bob.Send("typo hello")
+	id := strings.TrimPrefix(strings.Fields(carol.Expect("bob:typo hello"))[0], "#")
+	bob.Send("edit|" + id + "|fixed hello")
+	carol.Expect("edit|" + id)
+
+	// 修改后的内容也能查到
+	carol.Send("search|hello")
+	carol.Expect(zh(i18n.SearchFound, 2))
+	carol.Expect("alice：hello everyone")
+	carol.Expect("bob：fixed hello")
+
+	// 没有认证身份的用户即使参与了私聊也只能查到公聊
+	bob.Send("search|hello|from=alice")
+	bob.Expect(zh(i18n.SearchFound, 1))
+	bob.Expect("alice：hello everyone")
+	bob.Send("search||with=alice")
+	bob.Expect(zh(i18n.SearchNone))
+
+	carol.Send("search|nothing")
+	carol.Expect(zh(i18n.SearchNone))
+	carol.Send("search|x|since=yesterday")
+	carol.Expect(zh(i18n.SearchBadFormat))
+}
+
+// 认证过身份的用户可以查到自己参与的私聊，下线后别人改成同样的名字也查不到
+func TestSearchPrivate(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	cfg := config.Default()
+	cfg.ChatLog = filepath.Join(t.TempDir(), "chat.jsonl")
+	cfg.UnixAuth = config.UnixAuth{Enabled: true, Users: map[int]string{os.Getuid(): "alice"}}
+	tcp := chattest.NewServerConfig(t, cfg)
+	unix := tcp.ServeUnix()
+
+	bob := tcp.Dial()
+	bob.Rename("bob")
+	alice := unix.DialRaw()
+	alice.Expect(zh(i18n.AuthDone, "alice", os.Getuid()))
+	bob.Expect("]alice:" + zh(i18n.UserOnline))
+
+	alice.Send("to|bob|the secret plan")
+	bob.Expect("the secret plan")
+	bob.Send("to|alice|sounds good")
+	alice.Expect("sounds good")
+
+	alice.Send("search||with=bob")
+	alice.Expect(zh(i18n.SearchFound, 2))
+	alice.Expect("alice对bob说：the secret plan")
+	alice.Expect("bob对alice说：sounds good")
+
+	alice.Close()
+	bob.Expect("]alice:" + zh(i18n.UserOffline))
+
+	mallory := tcp.Dial()
+	mallory.Rename("alice")
+	mallory.Send("search||with=bob")
+	mallory.Expect(zh(i18n.SearchNone))
+	mallory.Send("search|secret")
+	mallory.Expect(zh(i18n.SearchNone))
+}
+
+func TestSearchDisabled(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Send("search|hello")
+	alice.Expect(zh(i18n.SearchDisabled))
+}
+
+// KCP上的聊天在丢包严重的网络上仍然可靠、有序
+func TestKCPLossyLink(t *testing.T) {
+	s := chattest.NewKCPServer(t, 0.2)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+
+	for i := 0; i < 50; i++ {
+		alice.Send("msg " + strconv.Itoa(i))
+	}
+	for i := 0; i < 50; i++ {
+		bob.Expect("alice:msg " + strconv.Itoa(i))
+	}
+
+	// 超过一个UDP包的消息
+	long := strings.Repeat("大", 1000)
+	bob.Send("to|alice|" + long)
+	alice.Expect(zh(i18n.PrivateFrom, "bob", long))
+}
+
+// 同一个服务器同时在TCP和Unix socket上提供服务，Unix socket的连接按照uid自动认证
+func TestUnixSocketAutoAuth(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	cfg := config.Default()
+	cfg.UnixAuth = config.UnixAuth{Enabled: true, Users: map[int]string{os.Getuid(): "agent"}}
+	tcp := chattest.NewServerConfig(t, cfg)
+	unix := tcp.ServeUnix()
+
+	human := tcp.Dial()
+	human.Rename("human")
+
+	agent := unix.DialRaw()
+	agent.Expect(zh(i18n.AuthDone, "agent", os.Getuid()))
+	human.Expect("]agent:" + zh(i18n.UserOnline))
+
+	agent.Send("hello from a local tool")
+	human.Expect("agent:hello from a local tool")
+	human.Send("to|agent|hi agent")
+	agent.Expect(zh(i18n.PrivateFrom, "human", "hi agent"))
+
+	// 同一个uid的第二个连接使用默认的用户名
+	second := unix.DialRaw()
+	second.Expect(zh(i18n.AuthNameTaken, "agent"))
+	second.Expect("]unix:")
+
+	// 没有开启时不认证
+	cfg = tcp.Config()
+	cfg.UnixAuth.Enabled = false
+	tcp.Reload(cfg)
+	third := unix.Dial()
+	third.ExpectNone(zh(i18n.AuthDone, "agent", os.Getuid()), 200*time.Millisecond)
+	if _, ok := tcp.Users().Get(third.Addr); !ok {
+		t.Fatalf("%s is not online with its default name", third.Addr)
+	}
+}
+
+// 关注用户的状态变化，按状态过滤在线用户
+func TestPresence(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+
+	bob.Send("watch|alice")
+	bob.Expect(zh(i18n.WatchDone, "alice"))
+
+	alice.Send("status|busy|开会中")
+	busy := zh(i18n.StatusWithMessage, zh(i18n.PresenceBusy), "开会中")
+	alice.Expect(zh(i18n.StatusDone, busy))
+	bob.Expect(zh(i18n.PresenceChanged, "["+alice.Addr+"]alice", busy))
+
+	bob.Send("who|busy")
+	bob.Expect(zh(i18n.WhoEntry, 1, alice.Addr, "alice", busy))
+	bob.ExpectNone("]bob:", 200*time.Millisecond)
+	bob.Send("who|sleeping")
+	bob.Expect(zh(i18n.WhoBadFormat))
+
+	alice.Send("status|sleeping")
+	alice.Expect(zh(i18n.StatusBadFormat))
+
+	// 取消关注之后不再收到通知
+	bob.Send("unwatch|alice")
+	bob.Expect(zh(i18n.UnwatchDone, "alice"))
+	alice.Send("status|available")
+	alice.Expect(zh(i18n.StatusDone, zh(i18n.PresenceAvailable)))
+	bob.ExpectNone(zh(i18n.PresenceChanged, "["+alice.Addr+"]alice", zh(i18n.PresenceAvailable)), 200*time.Millisecond)
+}
+
+// 不活跃时自动设置为离开，重新活跃时恢复为在线
+func TestAutoAway(t *testing.T) {
+	s := chattest.NewServer(t, func(s *core.Server) {
+		cfg := s.Config()
+		cfg.Timeouts.Away = 200 * time.Millisecond
+		cfg.Timeouts.Kick = 0
+		s.Reload(cfg)
+	})
+	bob := s.Dial()
+	bob.Rename("bob")
+	bob.Send("watch|alice")
+	bob.Expect(zh(i18n.WatchDone, "alice"))
+
+	alice := s.Dial()
+	alice.Rename("alice")
+	who := "[" + alice.Addr + "]alice"
+	bob.Expect(zh(i18n.PresenceChanged, who, zh(i18n.PresenceAway)))
+
+	alice.Send("I'm back")
+	bob.Expect(zh(i18n.PresenceChanged, who, zh(i18n.PresenceAvailable)))
+
+	// 手动设置的忙碌不会被自动改成离开，也不会因为活跃而恢复
+	alice.Send("status|busy")
+	bob.Expect(zh(i18n.PresenceChanged, who, zh(i18n.PresenceBusy)))
+	bob.ExpectNone(zh(i18n.PresenceAway), 400*time.Millisecond)
+	alice.Send("still busy")
+	bob.ExpectNone(zh(i18n.PresenceAvailable), 200*time.Millisecond)
+}
+
+// 从 "#ID [addr]name:消息" 中取出消息ID
+func msgID(line string) string {
+	return strings.TrimPrefix(strings.Fields(line)[0], "#")
+}
+
+// "正在输入"的通知不发给自己，私聊时只发给对方，并且有频率限制
+func TestTyping(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+	carol := s.Dial()
+	carol.Rename("carol")
+
+	alice.Send("typing")
+	bob.Expect("typing|alice")
+	carol.Expect("typing|alice")
+	alice.ExpectNone("typing|alice", 200*time.Millisecond)
+
+	// TypingLimit 之内的第二次通知被忽略
+	alice.Send("typing")
+	bob.ExpectNone("typing|alice", 200*time.Millisecond)
+
+	carol.Send("typing|bob")
+	bob.Expect("typing|carol")
+	alice.ExpectNone("typing|carol", 200*time.Millisecond)
+}
+
+// 修改和删除自己最近发送的公聊和私聊消息
+func TestEditDelete(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+	carol := s.Dial()
+	carol.Rename("carol")
+
+	alice.Send("helo")
+	id := msgID(bob.Expect("alice:helo"))
+	alice.Send("edit|" + id + "|hello")
+	bob.Expect("edit|" + id + "|[" + alice.Addr + "]alice:hello")
+	carol.Expect("edit|" + id + "|")
+
+	// 只能修改自己的消息
+	bob.Send("edit|" + id + "|hacked")
+	bob.Expect(zh(i18n.EditNotOwner))
+	bob.Send("delete|#" + id)
+	bob.Expect(zh(i18n.EditNotOwner))
+
+	alice.Send("delete|#" + id)
+	bob.Expect("delete|" + id)
+	carol.Expect("delete|" + id)
+	alice.Send("edit|" + id + "|again")
+	alice.Expect(zh(i18n.EditNotFound))
+
+	alice.Send("edit|abc|x")
+	alice.Expect(zh(i18n.EditBadFormat))
+	alice.Send("edit|" + id)
+	alice.Expect(zh(i18n.EditBadFormat))
+
+	// 私聊消息的修改只发给双方
+	alice.Send("to|bob|secrte")
+	id = msgID(bob.Expect(zh(i18n.PrivateFrom, "alice", "secrte")))
+	alice.Send("edit|" + id + "|secret")
+	bob.Expect("edit|" + id + "|" + zh(i18n.PrivateFrom, "alice", "secret"))
+	alice.Expect("edit|" + id + "|" + zh(i18n.PrivateSent, "bob", "secret"))
+	carol.ExpectNone("edit|"+id, 200*time.Millisecond)
+}
+
+// @到的用户和设置了关键字的用户单独收到提醒
+func TestMentionsAndKeywords(t *testing.T) {
+	s := chattest.NewServer(t)
+	alice := s.Dial()
+	alice.Rename("alice")
+	bob := s.Dial()
+	bob.Rename("bob")
+	carol := s.Dial()
+	carol.Rename("carol")
+
+	bob.Send("keyword|add|Pizza")
+	bob.Expect(zh(i18n.KeywordAdded, "Pizza"))
+	carol.Send("keyword|add|lunch")
+	carol.Expect(zh(i18n.KeywordAdded, "lunch"))
+
+	// 被@到时只收到mention提醒，关键字不区分大小写
+	alice.Send("@carol, lunch? pizza!")
+	line := "[" + alice.Addr + "]alice:@carol, lunch? pizza!"
+	id := msgID(alice.Expect(line))
+	carol.Expect("mention|" + id + "|" + line)
+	bob.Expect("keyword|" + id + "|Pizza|" + line)
+	carol.ExpectNone("keyword|", 200*time.Millisecond)
+	alice.ExpectNone("mention|", 100*time.Millisecond)
+
+	// 自己的消息不提醒自己
+	bob.Send("pizza again")
+	bob.ExpectNone("keyword|", 200*time.Millisecond)
+
+	bob.Send("keyword|list")
+	bob.Expect(zh(i18n.KeywordList, "Pizza"))
+	bob.Send("keyword|del|pizza")
+	bob.Expect(zh(i18n.KeywordRemoved, "pizza"))
+	alice.Send("more pizza")
+	bob.ExpectNone("keyword|", 200*time.Millisecond)
+
+	bob.Send("keyword|add|")
+	bob.Expect(zh(i18n.KeywordBadFormat))
+	for i := 0; i < session.MaxKeywords; i++ {
+		bob.Send("keyword|add|word" + strconv.Itoa(i))
+	}
+	bob.Send("keyword|add|one too many")
+	bob.Expect(zh(i18n.KeywordTooMany, session.MaxKeywords))
+}
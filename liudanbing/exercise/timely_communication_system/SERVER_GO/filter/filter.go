@@ -0,0 +1,77 @@
+// filter 是聊天消息的内容过滤，消息在广播或者私聊之前依次经过过滤链中的每一个环节，
+// 每个环节可以放行、修改或者拒绝消息，被拒绝的原因会发回给发送者
+package filter
+
+import (
+	"SERVER_GO/config"
+	"SERVER_GO/i18n"
+	"fmt"
+)
+
+// Message 是一条待过滤的消息
+type Message struct {
+	From   string // 发送者的用户名
+	To     string // 私聊对象，公聊时为空
+	Text   string
+	Sender any // 发送者本身(服务器中是 *session.User)，改名之后仍然相同，为nil时按From区分发送者
+}
+
+// 区分发送者的key，用户名可以随时修改，所以优先使用Sender
+func (m Message) senderKey() any {
+	if m.Sender != nil {
+		return m.Sender
+	}
+	return m.From
+}
+
+// Rejection 是消息被拒绝的原因，按发送者的语言翻译后发回给发送者
+type Rejection struct {
+	Stage string // 拒绝消息的环节
+	Key   i18n.Key
+	Args  []any
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("%s: %s", r.Stage, i18n.T(i18n.EnUS, r.Key, r.Args...))
+}
+
+// Stage 是过滤链中的一个环节
+// 返回修改后的内容(不修改时原样返回)，拒绝时返回 *Rejection
+type Stage interface {
+	Name() string
+	Filter(msg Message) (string, error)
+}
+
+// Chain 按顺序执行每一个环节，前一个环节修改后的内容交给下一个环节
+type Chain []Stage
+
+// Apply 过滤一条消息，返回最终的内容
+func (c Chain) Apply(msg Message) (string, error) {
+	for _, stage := range c {
+		text, err := stage.Filter(msg)
+		if err != nil {
+			return "", err
+		}
+		msg.Text = text
+	}
+	return msg.Text, nil
+}
+
+// New 按照配置创建过滤链，顺序为：长度 -> 屏蔽词 -> 链接 -> 重复消息
+// 重复消息放在最后，比较的是其它环节处理之后的内容
+func New(c config.Filters) Chain {
+	var chain Chain
+	if c.MaxLength > 0 {
+		chain = append(chain, MaxLength{Max: c.MaxLength})
+	}
+	if len(c.Blocklist) > 0 {
+		chain = append(chain, NewBlocklist(c.Blocklist))
+	}
+	if c.StripLinks {
+		chain = append(chain, StripLinks{})
+	}
+	if c.Spam.Repeat > 0 {
+		chain = append(chain, NewSpam(c.Spam.Repeat, c.Spam.Window))
+	}
+	return chain
+}
@@ -0,0 +1,93 @@
+package filter
+
+import (
+	"SERVER_GO/config"
+	"SERVER_GO/i18n"
+	"errors"
+	"testing"
+	"time"
+)
+
+func rejected(t *testing.T, err error, key i18n.Key) {
+	t.Helper()
+	var r *Rejection
+	if !errors.As(err, &r) || r.Key != key {
+		t.Fatalf("err = %v, want rejection %s", err, key)
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := New(config.Filters{
+		MaxLength:  20,
+		Blocklist:  []string{"darn", "坏话"},
+		StripLinks: true,
+	})
+
+	for _, tc := range []struct{ in, want string }{
+		{"hello", "hello"},
+		{"DARN it", "**** it"},
+		{"不要说坏话", "不要说**"},
+		{"see https://x.io/a?b", "see [link]"},
+		{"www.x.io darn", "[link] ****"},
+	} {
+		got, err := chain.Apply(Message{From: "alice", Text: tc.in})
+		if err != nil || got != tc.want {
+			t.Errorf("Apply(%q) = %q, %v, want %q", tc.in, got, err, tc.want)
+		}
+	}
+
+	_, err := chain.Apply(Message{From: "alice", Text: "这一条消息有二十一个字这一条消息有二十一个字"})
+	rejected(t, err, i18n.FilterTooLong)
+
+	if got, err := New(config.Filters{}).Apply(Message{Text: "darn https://x.io"}); err != nil || got != "darn https://x.io" {
+		t.Errorf("empty chain changed the message: %q, %v", got, err)
+	}
+}
+
+func TestSpam(t *testing.T) {
+	now := time.Unix(0, 0)
+	spam := NewSpam(3, 10*time.Second)
+	spam.now = func() time.Time { return now }
+
+	send := func(from, text string) error {
+		_, err := spam.Filter(Message{From: from, Text: text})
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := send("alice", "buy now"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rejected(t, send("alice", "  BUY   now "), i18n.FilterSpam)
+
+	// 其它用户和其它内容不受影响
+	if err := send("bob", "buy now"); err != nil {
+		t.Fatal(err)
+	}
+	if err := send("alice", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一个发送者改名之后仍然被拒绝
+	carol := new(int)
+	for _, name := range []string{"carol", "carol2"} {
+		if _, err := spam.Filter(Message{From: name, Text: "spam", Sender: carol}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := spam.Filter(Message{From: "carol3", Text: "spam", Sender: carol})
+	rejected(t, err, i18n.FilterSpam)
+
+	// 时间窗口过去之后可以再次发送
+	for i := 0; i < 2; i++ {
+		if err := send("alice", "buy now"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rejected(t, send("alice", "buy now"), i18n.FilterSpam)
+	now = now.Add(11 * time.Second)
+	if err := send("alice", "buy now"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,135 @@
+package filter
+
+import (
+	"SERVER_GO/i18n"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxLength 拒绝超过Max个字符的消息
+type MaxLength struct {
+	Max int
+}
+
+func (s MaxLength) Name() string { return "max_length" }
+
+func (s MaxLength) Filter(msg Message) (string, error) {
+	if utf8.RuneCountInString(msg.Text) > s.Max {
+		return "", &Rejection{Stage: s.Name(), Key: i18n.FilterTooLong, Args: []any{s.Max}}
+	}
+	return msg.Text, nil
+}
+
+// Blocklist 把消息中的屏蔽词替换成同样长度的*，不区分大小写
+type Blocklist struct {
+	re *regexp.Regexp
+}
+
+func NewBlocklist(words []string) *Blocklist {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return &Blocklist{}
+	}
+	return &Blocklist{re: regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))}
+}
+
+func (s *Blocklist) Name() string { return "blocklist" }
+
+func (s *Blocklist) Filter(msg Message) (string, error) {
+	if s.re == nil {
+		return msg.Text, nil
+	}
+	return s.re.ReplaceAllStringFunc(msg.Text, func(w string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(w))
+	}), nil
+}
+
+// 消息中的链接，例如 https://example.com、www.example.com
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)\S+`)
+
+// 链接被替换成的内容
+const LinkPlaceholder = "[link]"
+
+// StripLinks 去掉消息中的链接
+type StripLinks struct{}
+
+func (StripLinks) Name() string { return "strip_links" }
+
+func (StripLinks) Filter(msg Message) (string, error) {
+	return linkPattern.ReplaceAllString(msg.Text, LinkPlaceholder), nil
+}
+
+// Spam 拒绝同一个用户在Window时间内第Repeat次发送相同的消息，改名不会重新计数
+type Spam struct {
+	Repeat int
+	Window time.Duration
+
+	lock   sync.Mutex
+	last   map[any]*spamState // key: Message.senderKey()
+	pruned time.Time          // 上一次清理过期记录的时间
+	now    func() time.Time
+}
+
+// 一个用户最近发送的消息
+type spamState struct {
+	text  string
+	times []time.Time // 最近发送相同消息的时间
+}
+
+func NewSpam(repeat int, window time.Duration) *Spam {
+	return &Spam{Repeat: repeat, Window: window, last: make(map[any]*spamState), now: time.Now}
+}
+
+func (s *Spam) Name() string { return "spam" }
+
+func (s *Spam) Filter(msg Message) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	text := strings.ToLower(strings.Join(strings.Fields(msg.Text), " ")) // 忽略大小写和空白的差别
+
+	key := msg.senderKey()
+	st, ok := s.last[key]
+	if !ok || st.text != text {
+		s.last[key] = &spamState{text: text, times: []time.Time{now}}
+		s.prune(now)
+		return msg.Text, nil
+	}
+
+	// 只保留时间窗口内的记录
+	recent := st.times[:0]
+	for _, t := range st.times {
+		if now.Sub(t) < s.Window {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent)+1 >= s.Repeat {
+		st.times = recent
+		return "", &Rejection{Stage: s.Name(), Key: i18n.FilterSpam}
+	}
+	st.times = append(recent, now)
+	return msg.Text, nil
+}
+
+// 删除已经过期的用户记录，避免下线的用户一直占用内存，每个时间窗口最多清理一次
+func (s *Spam) prune(now time.Time) {
+	if now.Sub(s.pruned) < s.Window {
+		return
+	}
+	s.pruned = now
+
+	for key, st := range s.last {
+		if now.Sub(st.times[len(st.times)-1]) >= s.Window {
+			delete(s.last, key)
+		}
+	}
+}
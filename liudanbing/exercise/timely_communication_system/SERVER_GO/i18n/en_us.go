@@ -0,0 +1,74 @@
+package i18n
+
+var enUS = map[Key]string{
+	UserOnline:  "is online",
+	UserOffline: "is offline",
+	UserKicked:  "You have been kicked for inactivity",
+
+	WhoEntry:     "%d:[%s]%s:%s",
+	WhoBadFormat: "Invalid format, please use \"who|all/available/away/busy|name/status\"",
+
+	RenameTaken:    "This user name is already taken",
+	RenameReserved: "\"exit\" cannot be used as a user name",
+	RenameDone:     "Your user name is now: %s",
+	RenameEmpty:    "User name cannot be empty",
+
+	PrivateBadFormat: "Invalid format, please use \"to|name|message\"",
+	PrivateNoUser:    "No such user",
+	PrivateEmpty:     "Message is empty, please resend",
+	PrivateFrom:      "%s says to you: %s",
+	PrivateSent:      "You say to %s: %s",
+
+	EditBadFormat: "Invalid format, please use \"edit|id|new text\" or \"delete|id\"",
+	EditNotFound:  "The message does not exist or can no longer be changed",
+	EditNotOwner:  "You can only edit or delete your own messages",
+
+	PresenceAvailable: "online",
+	PresenceAway:      "away",
+	PresenceBusy:      "busy",
+	PresenceChanged:   "%s is now %s",
+	StatusWithMessage: "%s (%s)",
+	StatusDone:        "Your status is now: %s",
+	StatusBadFormat:   "Invalid format, please use \"status|available/away/busy|custom message\"",
+	WatchDone:         "Watching presence of %s",
+	UnwatchDone:       "Stopped watching presence of %s",
+
+	KeywordAdded:     "Keyword alert added: %s",
+	KeywordRemoved:   "Keyword alert removed: %s",
+	KeywordList:      "Your keyword alerts: %s",
+	KeywordTooMany:   "You can set at most %d keyword alerts",
+	KeywordBadFormat: "Invalid format, please use \"keyword|add|word\", \"keyword|del|word\" or \"keyword|list\"",
+
+	LangSwitched:    "Language switched to: %s",
+	LangUnsupported: "Unsupported language: %s, available: %s",
+
+	PrivateStored: "%s is offline, the message will be delivered when they come back",
+
+	WelcomeMOTD:   "Message of the day: %s",
+	WelcomeOnline: "%d users online: %s",
+	WelcomeHelp:   "Tip: who lists online users, rename|name changes your name, to|name|message sends a private message, anything else goes to everyone",
+	MailHeader:    "You have %d unread offline messages:",
+	MailEntry:     "[%s] %s said to you: %s",
+	MOTDEmpty:     "(not set)",
+	MOTDChanged:   "Message of the day updated",
+	MOTDNotAdmin:  "Only admins can change the message of the day",
+
+	SearchFound:     "Found %d messages:",
+	SearchPublic:    "#%d [%s] %s: %s",
+	SearchPrivate:   "#%d [%s] %s to %s: %s",
+	SearchNone:      "No matching messages",
+	SearchBadFormat: "Invalid format, please use \"search|keyword\", optionally with filters: |from=name |with=name |room=public |since=2024-01-02 |until=2024-01-03",
+	SearchDisabled:  "Chat log is not enabled on this server",
+
+	FilterTooLong: "Message too long, at most %d characters",
+	FilterSpam:    "Please do not repeat the same message",
+
+	AuthDone:      "Automatically authenticated as %s (uid %d) by local peer credentials",
+	AuthNameTaken: "The name %s for your local identity is already taken, using the default name",
+
+	CalcSyntax: "syntax error",
+	CalcFailed: "calculation failed (e.g. division by zero)",
+
+	ServerFull:  "The server is full (at most %d users online), please try again later",
+	LineTooLong: "Message too long, at most %d bytes",
+}
@@ -0,0 +1,65 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Locale 表示一种语言，例如 zh-CN、en-US
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN"
+	EnUS Locale = "en-US"
+
+	// 未协商语言的连接使用的默认语言
+	Default = ZhCN
+)
+
+// Key 是消息目录中的一条消息的标识
+type Key string
+
+// catalogs 记录每种语言对应的消息目录
+var catalogs = map[Locale]map[Key]string{
+	ZhCN: zhCN,
+	EnUS: enUS,
+}
+
+// Supported 返回服务器支持的全部语言
+func Supported() []Locale {
+	return []Locale{ZhCN, EnUS}
+}
+
+// Parse 将客户端传来的语言标识转换成Locale，支持 zh、zh_CN、zh-CN.UTF-8 这类写法
+func Parse(s string) (Locale, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, ".@"); i >= 0 { // 去掉编码部分，例如 .UTF-8
+		s = s[:i]
+	}
+	s = strings.ToLower(strings.ReplaceAll(s, "_", "-"))
+
+	switch {
+	case s == "zh" || strings.HasPrefix(s, "zh-"):
+		return ZhCN, true
+	case s == "en" || strings.HasPrefix(s, "en-"):
+		return EnUS, true
+	}
+	return "", false
+}
+
+// T 按照指定语言翻译消息，args 用于填充消息中的占位符
+// 找不到对应语言时使用默认语言，找不到对应key时直接返回key
+func T(l Locale, key Key, args ...any) string {
+	format, ok := catalogs[l][key]
+	if !ok {
+		format, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return string(key)
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
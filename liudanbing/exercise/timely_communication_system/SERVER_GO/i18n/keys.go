@@ -0,0 +1,75 @@
+package i18n
+
+// 服务器发给客户端的全部消息
+const (
+	UserOnline  Key = "user.online"
+	UserOffline Key = "user.offline"
+	UserKicked  Key = "user.kicked"
+
+	WhoEntry     Key = "who.entry"
+	WhoBadFormat Key = "who.bad_format"
+
+	RenameTaken    Key = "rename.taken"
+	RenameReserved Key = "rename.reserved"
+	RenameDone     Key = "rename.done"
+	RenameEmpty    Key = "rename.empty"
+
+	PrivateBadFormat Key = "private.bad_format"
+	PrivateNoUser    Key = "private.no_user"
+	PrivateEmpty     Key = "private.empty"
+	PrivateFrom      Key = "private.from"
+	PrivateSent      Key = "private.sent"
+
+	EditBadFormat Key = "edit.bad_format"
+	EditNotFound  Key = "edit.not_found"
+	EditNotOwner  Key = "edit.not_owner"
+
+	PresenceAvailable Key = "presence.available"
+	PresenceAway      Key = "presence.away"
+	PresenceBusy      Key = "presence.busy"
+	PresenceChanged   Key = "presence.changed"
+	StatusWithMessage Key = "status.with_message"
+	StatusDone        Key = "status.done"
+	StatusBadFormat   Key = "status.bad_format"
+	WatchDone         Key = "watch.done"
+	UnwatchDone       Key = "watch.undone"
+
+	KeywordAdded     Key = "keyword.added"
+	KeywordRemoved   Key = "keyword.removed"
+	KeywordList      Key = "keyword.list"
+	KeywordTooMany   Key = "keyword.too_many"
+	KeywordBadFormat Key = "keyword.bad_format"
+
+	LangSwitched    Key = "lang.switched"
+	LangUnsupported Key = "lang.unsupported"
+
+	PrivateStored Key = "private.stored"
+
+	WelcomeMOTD   Key = "welcome.motd"
+	WelcomeOnline Key = "welcome.online"
+	WelcomeHelp   Key = "welcome.help"
+	MailHeader    Key = "mail.header"
+	MailEntry     Key = "mail.entry"
+	MOTDEmpty     Key = "motd.empty"
+	MOTDChanged   Key = "motd.changed"
+	MOTDNotAdmin  Key = "motd.not_admin"
+
+	SearchFound     Key = "search.found"
+	SearchPublic    Key = "search.public"
+	SearchPrivate   Key = "search.private"
+	SearchNone      Key = "search.none"
+	SearchBadFormat Key = "search.bad_format"
+	SearchDisabled  Key = "search.disabled"
+
+	FilterTooLong Key = "filter.too_long"
+	FilterSpam    Key = "filter.spam"
+
+	AuthDone      Key = "auth.done"
+	AuthNameTaken Key = "auth.name_taken"
+
+	CalcSyntax Key = "calc.syntax"
+	CalcFailed Key = "calc.failed"
+
+	ServerFull  Key = "limit.server_full"
+	LineTooLong Key = "limit.line_too_long"
+)
@@ -0,0 +1,74 @@
+package i18n
+
+var zhCN = map[Key]string{
+	UserOnline:  "已上线",
+	UserOffline: "已下线",
+	UserKicked:  "你被踢了",
+
+	WhoEntry:     "%d:[%s]%s:%s",
+	WhoBadFormat: "查询格式不正确，请使用\"who|all/available/away/busy|name/status\"",
+
+	RenameTaken:    "当前用户名被使用",
+	RenameReserved: "禁止使用exit作为用户名",
+	RenameDone:     "您已经更新用户名:%s",
+	RenameEmpty:    "用户名不能为空",
+
+	PrivateBadFormat: "消息格式不正确，请使用\"to|张三|消息内容\"",
+	PrivateNoUser:    "该用户名不存在",
+	PrivateEmpty:     "无消息内容，请重发",
+	PrivateFrom:      "%s对您说：%s",
+	PrivateSent:      "您对%s说：%s",
+
+	EditBadFormat: "格式不正确，请使用\"edit|消息ID|新的内容\"或者\"delete|消息ID\"",
+	EditNotFound:  "消息不存在或者已经超过可修改的时间",
+	EditNotOwner:  "只能修改或删除自己发送的消息",
+
+	PresenceAvailable: "在线",
+	PresenceAway:      "离开",
+	PresenceBusy:      "忙碌",
+	PresenceChanged:   "%s 的状态变为：%s",
+	StatusWithMessage: "%s(%s)",
+	StatusDone:        "您的状态已更新为：%s",
+	StatusBadFormat:   "状态格式不正确，请使用\"status|available/away/busy|自定义消息\"",
+	WatchDone:         "已关注 %s 的状态变化",
+	UnwatchDone:       "已取消关注 %s 的状态变化",
+
+	KeywordAdded:     "已添加关键字提醒：%s",
+	KeywordRemoved:   "已删除关键字提醒：%s",
+	KeywordList:      "当前的关键字提醒：%s",
+	KeywordTooMany:   "最多只能设置%d个关键字提醒",
+	KeywordBadFormat: "格式不正确，请使用\"keyword|add|关键字\"、\"keyword|del|关键字\"或者\"keyword|list\"",
+
+	LangSwitched:    "语言已切换为：%s",
+	LangUnsupported: "不支持的语言：%s，可选：%s",
+
+	PrivateStored: "%s 不在线，消息已保存，对方上线后会收到",
+
+	WelcomeMOTD:   "每日消息：%s",
+	WelcomeOnline: "当前在线%d人：%s",
+	WelcomeHelp:   "提示：who 查看在线用户，rename|名字 修改用户名，to|名字|消息 私聊，其它内容会发送给所有人",
+	MailHeader:    "您有%d条未读的离线消息：",
+	MailEntry:     "[%s] %s对您说：%s",
+	MOTDEmpty:     "(没有设置)",
+	MOTDChanged:   "每日消息已更新",
+	MOTDNotAdmin:  "只有管理员可以修改每日消息",
+
+	SearchFound:     "找到%d条消息：",
+	SearchPublic:    "#%d [%s] %s：%s",
+	SearchPrivate:   "#%d [%s] %s对%s说：%s",
+	SearchNone:      "没有找到相关的消息",
+	SearchBadFormat: "格式不正确，请使用\"search|关键字\"，可以加上条件：|from=用户名 |with=私聊对象 |room=public |since=2024-01-02 |until=2024-01-03",
+	SearchDisabled:  "服务器没有开启聊天记录",
+
+	FilterTooLong: "消息太长，最多%d个字",
+	FilterSpam:    "请不要重复发送相同的消息",
+
+	AuthDone:      "已通过本机身份自动认证为 %s (uid %d)",
+	AuthNameTaken: "本机身份对应的用户名 %s 已经被使用，当前使用默认的用户名",
+
+	CalcSyntax: "语法错误",
+	CalcFailed: "计算失败(例如除以0)",
+
+	ServerFull:  "服务器已满(最多%d人在线)，请稍后再试",
+	LineTooLong: "消息太长，最多%d个字节",
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 公聊消息中的压测标记，格式为 lt|发送时间(纳秒)
+const probePrefix = "lt|"
+
+// 记录每个模拟客户端当前的用户名，私聊时随机挑选对象
+type nameBook struct {
+	lock  sync.RWMutex
+	names []string
+}
+
+func newNameBook(n int) *nameBook {
+	return &nameBook{names: make([]string, n)}
+}
+
+func (b *nameBook) set(i int, name string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.names[i] = name
+}
+
+func (b *nameBook) pick(rnd *rand.Rand, except int) string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	i := rnd.Intn(len(b.names))
+	if i == except {
+		i = (i + 1) % len(b.names)
+	}
+	return b.names[i]
+}
+
+// 一个模拟客户端：一个goroutine按权重随机行动，一个goroutine持续读取服务器的消息
+type simClient struct {
+	id    int
+	cfg   *Config
+	stats *Stats
+	names *nameBook
+	rnd   *rand.Rand
+
+	conn    net.Conn
+	renames int
+}
+
+func (c *simClient) run(deadline time.Time) {
+	conn, err := net.DialTimeout("tcp", c.cfg.Addr, 5*time.Second)
+	if err != nil {
+		c.stats.Error("dial")
+		return
+	}
+	c.conn = conn
+	c.stats.Connected()
+
+	done := make(chan struct{})
+	go c.read(done)
+
+	c.rename()
+	for time.Now().Before(deadline) {
+		// 在 [0.5, 1.5) 倍的间隔内随机等待，避免所有客户端同时行动
+		wait := time.Duration(float64(c.cfg.Interval) * (0.5 + c.rnd.Float64()))
+		if rest := time.Until(deadline); wait > rest {
+			wait = rest
+		}
+
+		select {
+		case <-done:
+			c.stats.Error("disconnected")
+			return
+		case <-time.After(wait):
+		}
+
+		c.act()
+	}
+
+	conn.Close()
+	<-done
+}
+
+// 按照权重选择一种行为
+func (c *simClient) act() {
+	cfg := c.cfg
+	n := c.rnd.Intn(cfg.Rename + cfg.Broadcast + cfg.Whisper + cfg.Idle)
+	switch {
+	case n < cfg.Rename:
+		c.rename()
+	case n < cfg.Rename+cfg.Broadcast:
+		c.stats.Action("broadcast")
+		c.stats.BroadcastSent()
+		c.send(probePrefix + strconv.FormatInt(time.Now().UnixNano(), 10))
+	case n < cfg.Rename+cfg.Broadcast+cfg.Whisper:
+		c.stats.Action("whisper")
+		c.send("to|" + c.names.pick(c.rnd, c.id) + "|hello")
+	default:
+		c.stats.Action("idle")
+	}
+}
+
+func (c *simClient) rename() {
+	c.stats.Action("rename")
+	name := "lt-" + strconv.Itoa(c.id) + "-" + strconv.Itoa(c.renames)
+	c.renames++
+	if c.send("rename|" + name) {
+		c.names.set(c.id, name)
+	}
+}
+
+func (c *simClient) send(msg string) bool {
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.conn.Write([]byte(msg + "\n")); err != nil {
+		c.stats.Error("write")
+		return false
+	}
+	return true
+}
+
+// 读取服务器发来的消息，遇到压测标记时统计投递延迟
+func (c *simClient) read(done chan struct{}) {
+	defer close(done)
+
+	reader := bufio.NewReader(c.conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		i := strings.Index(line, probePrefix)
+		if i < 0 {
+			continue
+		}
+		sent, err := strconv.ParseInt(strings.TrimSpace(line[i+len(probePrefix):]), 10, 64)
+		if err != nil {
+			c.stats.Error("bad_probe")
+			continue
+		}
+		c.stats.Delivered(time.Since(time.Unix(0, sent)))
+	}
+}
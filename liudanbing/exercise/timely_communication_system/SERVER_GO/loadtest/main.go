@@ -0,0 +1,123 @@
+// loadtest 是聊天服务器的压测工具：打开N个模拟客户端，按比例执行改名、公聊、私聊和空闲，
+// 统计公聊消息的投递延迟、错误数以及服务器的内存和goroutine增长，结果写成JSON报告
+//
+//	go run ./loadtest -clients 200 -duration 30s -out report.json
+//
+// 不指定 -addr 时在进程内启动一个 core.Server(随机端口)，这样才能统计服务器的内存和goroutine
+package main
+
+import (
+	"SERVER_GO/config"
+	"SERVER_GO/core"
+	"SERVER_GO/transport"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Config 是压测的参数，会原样写进报告，方便对比两次压测
+type Config struct {
+	Addr     string        `json:"addr"`
+	Clients  int           `json:"clients"`
+	Duration time.Duration `json:"duration"`
+	Interval time.Duration `json:"interval"`
+	Ramp     time.Duration `json:"ramp"`
+	Seed     int64         `json:"seed"`
+
+	// 每个客户端每次行动时选择各种行为的权重
+	Rename    int `json:"rename"`
+	Broadcast int `json:"broadcast"`
+	Whisper   int `json:"whisper"`
+	Idle      int `json:"idle"`
+}
+
+func main() {
+	var cfg Config
+	var out string
+	flag.StringVar(&cfg.Addr, "addr", "", "服务器地址，例如127.0.0.1:8888；为空时在进程内启动服务器")
+	flag.IntVar(&cfg.Clients, "clients", 100, "模拟客户端的数量")
+	flag.DurationVar(&cfg.Duration, "duration", 30*time.Second, "压测持续时间")
+	flag.DurationVar(&cfg.Interval, "interval", time.Second, "每个客户端两次行动之间的平均间隔")
+	flag.DurationVar(&cfg.Ramp, "ramp", 5*time.Second, "在这段时间内逐步建立全部连接")
+	flag.Int64Var(&cfg.Seed, "seed", 1, "随机数种子，相同的种子产生相同的行为序列")
+	flag.IntVar(&cfg.Rename, "rename", 1, "改名(rename|)的权重")
+	flag.IntVar(&cfg.Broadcast, "broadcast", 6, "公聊的权重")
+	flag.IntVar(&cfg.Whisper, "whisper", 2, "私聊(to|)的权重")
+	flag.IntVar(&cfg.Idle, "idle", 1, "空闲(本次不发送任何消息)的权重")
+	flag.StringVar(&out, "out", "loadtest-report.json", "JSON报告的输出文件")
+	flag.Parse()
+
+	if cfg.Clients <= 0 || cfg.Rename+cfg.Broadcast+cfg.Whisper+cfg.Idle <= 0 {
+		fmt.Println("clients和各行为的权重必须大于0")
+		os.Exit(2)
+	}
+
+	stats := NewStats()
+	sampler := newRuntimeSampler(cfg.Addr == "")
+
+	if cfg.Addr == "" {
+		listener, err := transport.ListenTCP("127.0.0.1:0")
+		if err != nil {
+			fmt.Println("transport.ListenTCP err:", err)
+			os.Exit(1)
+		}
+		defer listener.Close()
+
+		serverCfg := config.Default()
+		serverCfg.Listen.Port = listener.Addr().(*net.TCPAddr).Port
+		serverCfg.Limits.MaxUsers = 0 // 压测的客户端数量不受在线人数限制
+		server := core.New(serverCfg)
+		go server.Serve(listener)
+		cfg.Addr = listener.Addr().String()
+	}
+
+	sampler.sample()
+	stop := make(chan struct{})
+	go sampler.run(stop)
+
+	fmt.Printf("压测开始：%d个客户端 -> %s，持续%s\n", cfg.Clients, cfg.Addr, cfg.Duration)
+	started := time.Now()
+	names := newNameBook(cfg.Clients)
+
+	var wg sync.WaitGroup
+	deadline := started.Add(cfg.Duration)
+	for i := 0; i < cfg.Clients; i++ {
+		c := &simClient{
+			id:    i,
+			cfg:   &cfg,
+			stats: stats,
+			names: names,
+			rnd:   rand.New(rand.NewSource(cfg.Seed + int64(i))),
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.run(deadline)
+		}()
+
+		if cfg.Ramp > 0 {
+			time.Sleep(cfg.Ramp / time.Duration(cfg.Clients))
+		}
+	}
+	wg.Wait()
+
+	// 等待服务器回收断开的连接后再采样一次
+	time.Sleep(500 * time.Millisecond)
+	close(stop)
+	runtime.GC()
+	sampler.sample()
+
+	report := stats.Report(cfg, started, time.Since(started), sampler)
+	if err := report.WriteFile(out); err != nil {
+		fmt.Println("写入报告失败:", err)
+		os.Exit(1)
+	}
+	report.Print()
+	fmt.Println("报告已写入", out)
+}
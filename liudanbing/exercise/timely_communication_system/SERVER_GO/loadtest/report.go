@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 最多保留多少个延迟样本，超过后用蓄水池抽样，避免大规模压测时内存无限增长
+const maxSamples = 100000
+
+// Stats 汇总全部模拟客户端的统计数据
+type Stats struct {
+	lock sync.Mutex
+
+	connected int
+	actions   map[string]int64
+	errors    map[string]int64
+
+	sent      int64
+	delivered int64
+	samples   []time.Duration
+	rnd       *rand.Rand
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		actions: make(map[string]int64),
+		errors:  make(map[string]int64),
+		samples: make([]time.Duration, 0, 1024),
+		rnd:     rand.New(rand.NewSource(1)),
+	}
+}
+
+func (s *Stats) Connected() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.connected++
+}
+
+func (s *Stats) Action(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.actions[name]++
+}
+
+func (s *Stats) Error(kind string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.errors[kind]++
+}
+
+func (s *Stats) BroadcastSent() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sent++
+}
+
+// 记录一次公聊消息投递到某个客户端的延迟
+func (s *Stats) Delivered(d time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.delivered++
+	if len(s.samples) < maxSamples {
+		s.samples = append(s.samples, d)
+	} else if i := s.rnd.Int63n(s.delivered); i < maxSamples {
+		s.samples[i] = d
+	}
+}
+
+// Latency 是延迟的分位数，单位为毫秒
+type Latency struct {
+	Samples int     `json:"samples"`
+	Min     float64 `json:"min_ms"`
+	P50     float64 `json:"p50_ms"`
+	P90     float64 `json:"p90_ms"`
+	P99     float64 `json:"p99_ms"`
+	Max     float64 `json:"max_ms"`
+}
+
+func percentiles(samples []time.Duration) Latency {
+	if len(samples) == 0 {
+		return Latency{}
+	}
+
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	at := func(p float64) float64 {
+		i := int(p * float64(len(sorted)-1))
+		return float64(sorted[i]) / float64(time.Millisecond)
+	}
+	return Latency{
+		Samples: len(sorted),
+		Min:     at(0),
+		P50:     at(0.50),
+		P90:     at(0.90),
+		P99:     at(0.99),
+		Max:     at(1),
+	}
+}
+
+// 定期采样进程的内存和goroutine数量，只有服务器在进程内运行时才有意义
+type runtimeSampler struct {
+	enabled bool
+	lock    sync.Mutex
+	samples []RuntimeSample
+}
+
+// RuntimeSample 是一次运行时采样
+type RuntimeSample struct {
+	Elapsed    string `json:"elapsed"`
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heap_alloc_bytes"`
+	Sys        uint64 `json:"sys_bytes"`
+}
+
+func newRuntimeSampler(enabled bool) *runtimeSampler {
+	return &runtimeSampler{enabled: enabled}
+}
+
+var processStart = time.Now()
+
+func (r *runtimeSampler) sample() {
+	if !r.enabled {
+		return
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.samples = append(r.samples, RuntimeSample{
+		Elapsed:    time.Since(processStart).Round(time.Millisecond).String(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  m.HeapAlloc,
+		Sys:        m.Sys,
+	})
+}
+
+func (r *runtimeSampler) run(stop chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.sample()
+		}
+	}
+}
+
+// RuntimeGrowth 汇总压测前、峰值以及压测结束后的运行时数据
+type RuntimeGrowth struct {
+	GoroutinesStart int             `json:"goroutines_start"`
+	GoroutinesPeak  int             `json:"goroutines_peak"`
+	GoroutinesEnd   int             `json:"goroutines_end"`
+	HeapStart       uint64          `json:"heap_start_bytes"`
+	HeapPeak        uint64          `json:"heap_peak_bytes"`
+	HeapEnd         uint64          `json:"heap_end_bytes"`
+	Samples         []RuntimeSample `json:"samples"`
+}
+
+func (r *runtimeSampler) growth() *RuntimeGrowth {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.samples) == 0 {
+		return nil
+	}
+
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	g := &RuntimeGrowth{
+		GoroutinesStart: first.Goroutines,
+		GoroutinesEnd:   last.Goroutines,
+		HeapStart:       first.HeapAlloc,
+		HeapEnd:         last.HeapAlloc,
+		Samples:         append([]RuntimeSample(nil), r.samples...),
+	}
+	for _, s := range r.samples {
+		g.GoroutinesPeak = max(g.GoroutinesPeak, s.Goroutines)
+		g.HeapPeak = max(g.HeapPeak, s.HeapAlloc)
+	}
+	return g
+}
+
+// Report 是写入JSON文件的压测报告
+type Report struct {
+	Config    Config           `json:"config"`
+	StartedAt time.Time        `json:"started_at"`
+	Elapsed   string           `json:"elapsed"`
+	Connected int              `json:"connected"`
+	Actions   map[string]int64 `json:"actions"`
+	Errors    map[string]int64 `json:"errors"`
+
+	BroadcastsSent      int64   `json:"broadcasts_sent"`
+	BroadcastsDelivered int64   `json:"broadcasts_delivered"`
+	BroadcastLatency    Latency `json:"broadcast_latency"`
+
+	// 只有在进程内启动服务器时才有
+	Runtime *RuntimeGrowth `json:"runtime,omitempty"`
+}
+
+func (s *Stats) Report(cfg Config, started time.Time, elapsed time.Duration, sampler *runtimeSampler) *Report {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return &Report{
+		Config:    cfg,
+		StartedAt: started,
+		Elapsed:   elapsed.Round(time.Millisecond).String(),
+		Connected: s.connected,
+		Actions:   s.actions,
+		Errors:    s.errors,
+
+		BroadcastsSent:      s.sent,
+		BroadcastsDelivered: s.delivered,
+		BroadcastLatency:    percentiles(s.samples),
+
+		Runtime: sampler.growth(),
+	}
+}
+
+func (r *Report) WriteFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(r)
+}
+
+func (r *Report) Print() {
+	fmt.Printf("连接成功：%d/%d，耗时：%s\n", r.Connected, r.Config.Clients, r.Elapsed)
+	fmt.Printf("行为：%v\n", r.Actions)
+	fmt.Printf("错误：%v\n", r.Errors)
+	fmt.Printf("公聊：发送%d条，投递%d次\n", r.BroadcastsSent, r.BroadcastsDelivered)
+	l := r.BroadcastLatency
+	fmt.Printf("投递延迟(ms)：min=%.2f p50=%.2f p90=%.2f p99=%.2f max=%.2f\n", l.Min, l.P50, l.P90, l.P99, l.Max)
+	if g := r.Runtime; g != nil {
+		fmt.Printf("goroutine：%d -> 峰值%d -> %d\n", g.GoroutinesStart, g.GoroutinesPeak, g.GoroutinesEnd)
+		fmt.Printf("堆内存(bytes)：%d -> 峰值%d -> %d\n", g.HeapStart, g.HeapPeak, g.HeapEnd)
+	}
+}
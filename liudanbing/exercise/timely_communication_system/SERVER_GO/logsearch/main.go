@@ -0,0 +1,112 @@
+// logsearch 是聊天记录的离线工具：查询服务器保存的聊天记录(配置中的 chat_log)，或者导出成 Markdown、JSON、CSV
+//
+//	go run ./logsearch search -log chat.jsonl -from alice -since 2024-01-02 hello
+//	go run ./logsearch export -log chat.jsonl -with bob -format md -out alice-bob.md
+//
+// 和服务器的 search| 命令不同，离线工具可以查到全部私聊
+package main
+
+import (
+	"SERVER_GO/chatlog"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法：logsearch search|export [参数] [关键字]")
+	fmt.Fprintln(os.Stderr, "  logsearch search -h 查看查询的参数")
+	fmt.Fprintln(os.Stderr, "  logsearch export -h 查看导出的参数")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "search":
+		err = run(os.Args[1], os.Args[2:], false)
+	case "export":
+		err = run(os.Args[1], os.Args[2:], true)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logsearch:", err)
+		os.Exit(1)
+	}
+}
+
+// 两个子命令使用同样的查询参数，export 多了格式和输出文件
+func run(name string, args []string, export bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	logFile := fs.String("log", "chat.jsonl", "聊天记录文件")
+	var q chatlog.Query
+	for _, key := range []string{"from", "with", "room", "since", "until"} {
+		fs.Func(key, filterUsage[key], func(v string) error { return q.Set(key, v) })
+	}
+	fs.IntVar(&q.Limit, "limit", 0, "最多输出多少条(保留最近的)，0表示不限制")
+	format := "text"
+	out := ""
+	if export {
+		fs.StringVar(&format, "format", "md", "导出格式："+strings.Join(chatlog.Formats, "、"))
+		fs.StringVar(&out, "out", "", "输出文件，为空时输出到标准输出")
+	}
+	fs.Parse(args)
+	q.Keyword = strings.Join(fs.Args(), " ")
+
+	entries, err := chatlog.ReadFile(*logFile)
+	if err != nil {
+		return err
+	}
+	entries = chatlog.Search(entries, q)
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if !export {
+		printEntries(w, entries)
+		return nil
+	}
+	if err := chatlog.Export(w, format, entries); err != nil {
+		return err
+	}
+	if out != "" {
+		fmt.Fprintf(os.Stderr, "已导出%d条消息到 %s\n", len(entries), out)
+	}
+	return nil
+}
+
+var filterUsage = map[string]string{
+	"from":  "发送者",
+	"with":  "只查询和这个用户之间的私聊",
+	"room":  "房间，例如 public，指定后不包括私聊",
+	"since": "开始时间，例如 2024-01-02 或者 \"2024-01-02 15:04\"",
+	"until": "结束时间(不包括)，只写日期时包括这一天",
+}
+
+func printEntries(w io.Writer, entries []chatlog.Entry) {
+	for _, e := range entries {
+		at := e.Time.Local().Format(time.DateTime)
+		if e.Private() {
+			fmt.Fprintf(w, "#%d [%s] %s -> %s: %s\n", e.ID, at, e.From, e.To, e.Text)
+		} else {
+			fmt.Fprintf(w, "#%d [%s] (%s) %s: %s\n", e.ID, at, e.Room, e.From, e.Text)
+		}
+	}
+	fmt.Fprintf(w, "共%d条消息\n", len(entries))
+}
@@ -1,13 +1,55 @@
 package main
 
 import (
-	"SERVER_GO/server_user"
+	"SERVER_GO/bots"
+	"SERVER_GO/config"
+	"SERVER_GO/core"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	// "timely_communication_system_server/user_mini"
 )
 
 func main() {
-	// 服务器的地址
-	server := server_user.NewServer("127.0.0.1", 8888)
+	// 服务器的配置：默认值 -> 配置文件 -> 环境变量 -> 命令行参数
+	loader := config.NewLoader()
+	loader.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Println("config.Load err:", err)
+		os.Exit(1)
+	}
+
+	server := core.New(cfg)
+	// 注册机器人
+	for _, name := range cfg.Features.Bots {
+		bot, err := bots.New(name)
+		if err != nil {
+			fmt.Println("bots.New err:", err)
+			os.Exit(1)
+		}
+		server.AddBot(bot)
+	}
+
+	// 收到SIGHUP时重新加载配置，配置有错误时继续使用原来的配置
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			cfg, err := loader.Load()
+			if err != nil {
+				fmt.Println("config reload err:", err)
+				continue
+			}
+			server.Reload(cfg)
+			fmt.Println("配置已重新加载")
+		}
+	}()
+
 	// 启动服务器
 	server.Start()
-}
\ No newline at end of file
+}
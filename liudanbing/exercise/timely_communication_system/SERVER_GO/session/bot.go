@@ -0,0 +1,80 @@
+package session
+
+import (
+	"SERVER_GO/i18n"
+)
+
+// Bot 是运行在服务器内部的机器人，它作为一个没有连接的虚拟用户在线
+// 私聊机器人(to|机器人|内容)或者在公聊中@机器人时，机器人会收到消息
+type Bot interface {
+	// 机器人的用户名
+	Name() string
+	// 处理一条消息，可以通过 self.Reply 回复
+	OnMessage(self *User, msg BotMessage)
+}
+
+// BotMessage 是机器人收到的一条消息
+type BotMessage struct {
+	From    *User  // 发送者
+	Text    string // 消息内容，公聊时去掉了@机器人的部分
+	Private bool   // 是否是私聊消息
+}
+
+// 机器人收件箱的大小，机器人处理不过来时丢弃新的消息
+const botInboxSize = 64
+
+// 创建机器人对应的虚拟用户，由服务器负责加入在线用户列表
+func NewBotUser(hub Hub, bot Bot) *User {
+	user := &User{
+		Addr: "bot",
+		C:    make(chan string, userChanSize),
+		done: make(chan struct{}),
+
+		watching: make(map[string]bool),
+
+		bot:   bot,
+		inbox: make(chan BotMessage, botInboxSize),
+
+		hub: hub,
+	}
+	user.name.Store(bot.Name())
+	user.lang.Store(i18n.Default)
+
+	go user.ListenMessage()
+	go user.runBot()
+
+	return user
+}
+
+// 是否是机器人
+func (u *User) IsBot() bool {
+	return u.bot != nil
+}
+
+// 机器人的消息循环，同一个机器人的消息按顺序处理
+func (u *User) runBot() {
+	for msg := range u.inbox {
+		u.bot.OnMessage(u, msg)
+	}
+}
+
+// 把消息投递给机器人，机器人之间的消息不投递，避免互相回复形成死循环
+func (u *User) deliver(msg BotMessage) {
+	if !u.IsBot() || msg.From.IsBot() {
+		return
+	}
+
+	select {
+	case u.inbox <- msg:
+	default:
+	}
+}
+
+// Reply 回复机器人收到的消息：私聊消息用私聊回复，公聊消息用公聊回复并@发送者
+func (u *User) Reply(msg BotMessage, text string) {
+	if msg.Private {
+		u.Whisper(msg.From, text)
+		return
+	}
+	u.hub.BroadCast(u, "@"+msg.From.Name()+" "+text)
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"SERVER_GO/i18n"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 通知其他用户"正在输入"，to为空时通知全部在线用户，否则只通知私聊对象
+// 同一个用户在TypingLimit内只会通知一次
+func (u *User) doTyping(to string) {
+	if time.Since(u.lastTyping) < TypingLimit {
+		return
+	}
+	u.lastTyping = time.Now()
+
+	frame := "typing|" + u.Name()
+	if to == "" {
+		u.hub.BroadCastFrame(frame, u)
+		return
+	}
+
+	remoteUser, ok := u.hub.Users().Get(to)
+	if ok {
+		remoteUser.SendMessage(frame + "\n")
+	}
+}
+
+// 修改自己最近发送的消息，消息格式：edit|消息ID|新的内容
+func (u *User) doEdit(args string) {
+	parts := strings.SplitN(args, "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		u.SendMessage(u.T(i18n.EditBadFormat) + "\n")
+		return
+	}
+
+	rec, ok := u.ownRecord(parts[0])
+	if !ok {
+		return
+	}
+
+	to := ""
+	if rec.To != nil {
+		to = rec.To.Name()
+	}
+	text, pass := u.filter(to, parts[1])
+	if !pass {
+		return
+	}
+	u.hub.History().Edit(rec.ID, text)
+
+	prefix := "edit|" + strconv.FormatUint(rec.ID, 10) + "|"
+	if rec.To == nil {
+		u.hub.BroadCastFrame(prefix+"["+u.Addr+"]"+u.Name()+":"+text, nil)
+		return
+	}
+	rec.To.SendMessage(prefix + rec.To.T(i18n.PrivateFrom, u.Name(), text) + "\n")
+	u.SendMessage(prefix + u.T(i18n.PrivateSent, rec.To.Name(), text) + "\n")
+}
+
+// 删除自己最近发送的消息，消息格式：delete|消息ID
+func (u *User) doDelete(args string) {
+	rec, ok := u.ownRecord(args)
+	if !ok {
+		return
+	}
+
+	u.hub.History().Delete(rec.ID)
+
+	frame := "delete|" + strconv.FormatUint(rec.ID, 10)
+	if rec.To == nil {
+		u.hub.BroadCastFrame(frame, nil)
+		return
+	}
+	rec.To.SendMessage(frame + "\n")
+	u.SendMessage(frame + "\n")
+}
+
+// 查找当前用户自己发送的、仍然可以修改的消息，找不到时给用户回复原因
+func (u *User) ownRecord(idStr string) (Record, bool) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(idStr, "#"), 10, 64)
+	if err != nil {
+		u.SendMessage(u.T(i18n.EditBadFormat) + "\n")
+		return Record{}, false
+	}
+
+	rec, ok := u.hub.History().Get(id)
+	if !ok {
+		u.SendMessage(u.T(i18n.EditNotFound) + "\n")
+		return Record{}, false
+	}
+	if rec.Sender != u {
+		u.SendMessage(u.T(i18n.EditNotOwner) + "\n")
+		return Record{}, false
+	}
+
+	return rec, true
+}
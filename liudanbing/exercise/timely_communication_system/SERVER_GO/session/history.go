@@ -0,0 +1,125 @@
+package session
+
+import (
+	"SERVER_GO/chatlog"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	HistorySize = 200              // 服务器最多保留多少条最近的消息
+	EditWindow  = 15 * time.Minute // 消息发出后多久之内可以修改或删除
+	TypingLimit = 3 * time.Second  // 同一个用户两次"正在输入"通知的最小间隔
+)
+
+// 服务器保留的一条消息，用于修改和删除
+type Record struct {
+	ID      uint64
+	Sender  *User
+	To      *User // 私聊对象，公聊时为nil
+	Text    string
+	Time    time.Time
+	Deleted bool
+}
+
+// 最近消息的环形窗口
+type History struct {
+	lock    sync.Mutex
+	records []*Record
+	nextID  uint64
+
+	log *chatlog.Writer // 持久化的聊天记录，为nil时不保存
+}
+
+func NewHistory() *History {
+	return &History{nextID: 1}
+}
+
+// 记录一条新消息，返回消息的ID
+func (h *History) Add(sender, to *User, text string) uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	rec := &Record{
+		ID:     h.nextID,
+		Sender: sender,
+		To:     to,
+		Text:   text,
+		Time:   time.Now(),
+	}
+	h.nextID++
+
+	h.records = append(h.records, rec)
+	if len(h.records) > HistorySize {
+		h.records = h.records[len(h.records)-HistorySize:]
+	}
+
+	entry := chatlog.Entry{ID: rec.ID, Time: rec.Time, From: sender.Name(), Text: text}
+	if to == nil {
+		entry.Room = chatlog.PublicRoom
+	} else {
+		entry.To = to.Name()
+	}
+	h.append(entry)
+
+	return rec.ID
+}
+
+// 设置聊天记录文件，在服务器开始服务之前调用
+func (h *History) SetLog(w *chatlog.Writer) {
+	h.log = w
+}
+
+// 写入聊天记录，调用者持有h.lock，保证记录的顺序和消息ID的顺序一致
+func (h *History) append(e chatlog.Entry) {
+	if h.log == nil {
+		return
+	}
+	if err := h.log.Append(e); err != nil {
+		fmt.Println("chatlog.Append err:", err)
+	}
+}
+
+// 查找仍然可以修改的消息，返回一份拷贝
+func (h *History) Get(id uint64) (Record, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	rec := h.find(id)
+	if rec == nil || rec.Deleted || time.Since(rec.Time) > EditWindow {
+		return Record{}, false
+	}
+	return *rec, true
+}
+
+// 修改消息内容
+func (h *History) Edit(id uint64, text string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if rec := h.find(id); rec != nil {
+		rec.Text = text
+		h.append(chatlog.Entry{Kind: chatlog.KindEdit, ID: id, Time: time.Now(), Text: text})
+	}
+}
+
+// 将消息标记为已删除
+func (h *History) Delete(id uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if rec := h.find(id); rec != nil {
+		rec.Deleted = true
+		h.append(chatlog.Entry{Kind: chatlog.KindDelete, ID: id, Time: time.Now()})
+	}
+}
+
+func (h *History) find(id uint64) *Record {
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].ID == id {
+			return h.records[i]
+		}
+	}
+	return nil
+}
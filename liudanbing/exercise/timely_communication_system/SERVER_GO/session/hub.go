@@ -0,0 +1,44 @@
+package session
+
+import (
+	"SERVER_GO/chatlog"
+	"SERVER_GO/filter"
+	"SERVER_GO/i18n"
+	"SERVER_GO/transport"
+)
+
+// Hub 是会话层需要用到的服务器能力，由 core.Server 实现
+// 会话层只依赖这个接口而不是服务器的具体实现，所以 session 不需要 import core，
+// 不会再出现 SERVER_GO_ERROR 中 server_mini 和 user_mini 互相引用的问题
+type Hub interface {
+	// 在线用户列表
+	Users() *Registry
+	// 最近的消息，用于修改和删除
+	History() *History
+	// 不在线用户的离线消息
+	Mailbox() *Mailbox
+	// 查询持久化的聊天记录
+	SearchLog(q chatlog.Query) ([]chatlog.Entry, error)
+
+	// 广播用户发送的消息
+	BroadCast(user *User, msg string)
+	// 广播系统消息，按每个接收者的语言翻译
+	BroadCastKey(user *User, key i18n.Key)
+	// 广播控制消息(typing/edit/delete)，except为空时发给全部用户
+	BroadCastFrame(frame string, except *User)
+
+	// 内容过滤，返回过滤后的消息，被拒绝时返回 *filter.Rejection
+	Filter(msg filter.Message) (string, error)
+
+	// 是否是不允许使用的用户名，例如 exit
+	IsReservedName(name string) bool
+	// 经过认证的身份name是否是管理员，见 User.Identity
+	IsAdmin(name string) bool
+	// Unix socket 连接按照对端进程的身份自动认证，返回对应的用户名
+	PeerName(cred transport.Credentials) (string, bool)
+
+	// 每日消息，用户上线时显示
+	MOTD() string
+	// 修改每日消息，重新加载配置时会被配置文件中的值覆盖
+	SetMOTD(motd string)
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// 每个用户名最多保存多少条离线消息，超过后拒绝新的离线消息
+const MailboxSize = 50
+
+// 一条离线消息
+type Mail struct {
+	From string
+	Text string
+	Time time.Time
+}
+
+// Mailbox 保存发给不在线用户的私聊消息，用户以认证过的身份再次上线时收到
+// 只有认证过的身份才能接收离线消息，避免给拼错的名字或者任何人都能使用的名字留言
+type Mailbox struct {
+	lock  sync.Mutex
+	known map[string]bool   // 认证过的身份
+	mails map[string][]Mail // key: 收件人的用户名
+}
+
+func NewMailbox() *Mailbox {
+	return &Mailbox{
+		known: make(map[string]bool),
+		mails: make(map[string][]Mail),
+	}
+}
+
+// 记录一个认证过的身份
+func (m *Mailbox) Remember(name string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.known[name] = true
+}
+
+// 保存一条离线消息，名字没有认证过或者收件箱已满时返回false
+func (m *Mailbox) Put(to string, mail Mail) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.known[to] || len(m.mails[to]) >= MailboxSize {
+		return false
+	}
+	m.mails[to] = append(m.mails[to], mail)
+	return true
+}
+
+// 取出发给name的全部离线消息
+func (m *Mailbox) Take(name string) []Mail {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mails := m.mails[name]
+	delete(m.mails, name)
+	return mails
+}
@@ -0,0 +1,142 @@
+package session
+
+import (
+	"SERVER_GO/i18n"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 每个用户最多可以设置多少个关键字提醒
+const MaxKeywords = 20
+
+// 找出消息中@到的用户名，例如 "@calc 1+2" 返回 ["calc"]
+func Mentions(msg string) []string {
+	names := make([]string, 0)
+	for _, field := range strings.FieldsFunc(msg, unicode.IsSpace) {
+		if len(field) > 1 && field[0] == '@' {
+			names = append(names, strings.TrimRightFunc(field[1:], unicode.IsPunct))
+		}
+	}
+	return names
+}
+
+// 公聊消息发出后的提醒：
+// 1. @到了机器人，把消息投递给机器人
+// 2. @到了用户，单独给该用户发送一条 mention|消息ID|消息 的提醒
+// 3. 消息中包含用户设置的关键字，给该用户发送一条 keyword|消息ID|关键字|消息 的提醒
+// 提醒直接写到用户的连接上，不经过广播的Message channel
+func NotifyMentions(hub Hub, user *User, id uint64, msg string) {
+	mentioned := make(map[string]bool)
+	for _, name := range Mentions(msg) {
+		mentioned[name] = true
+	}
+
+	type alert struct {
+		to    *User
+		frame string
+	}
+	alerts := make([]alert, 0)
+	line := "[" + user.Addr + "]" + user.Name() + ":" + msg
+	idStr := strconv.FormatUint(id, 10)
+
+	for _, cli := range hub.Users().Snapshot() {
+		if cli == user {
+			continue
+		}
+
+		name := cli.Name()
+
+		if mentioned[name] {
+			if cli.IsBot() {
+				text := strings.TrimSpace(strings.ReplaceAll(msg, "@"+name, ""))
+				cli.deliver(BotMessage{From: user, Text: text})
+			} else {
+				alerts = append(alerts, alert{cli, "mention|" + idStr + "|" + line})
+			}
+			continue
+		}
+
+		if word, ok := cli.MatchKeyword(msg); ok {
+			alerts = append(alerts, alert{cli, "keyword|" + idStr + "|" + word + "|" + line})
+		}
+	}
+
+	for _, a := range alerts {
+		a.to.SendMessage(a.frame + "\n")
+	}
+}
+
+// 消息中是否包含用户设置的关键字(不区分大小写)，返回匹配到的关键字
+func (u *User) MatchKeyword(msg string) (string, bool) {
+	u.statusLock.Lock()
+	defer u.statusLock.Unlock()
+
+	lower := strings.ToLower(msg)
+	for _, word := range u.keywords {
+		if strings.Contains(lower, strings.ToLower(word)) {
+			return word, true
+		}
+	}
+	return "", false
+}
+
+// 处理关键字提醒命令：keyword|add|关键字，keyword|del|关键字，keyword|list
+func (u *User) doKeyword(args string) {
+	parts := strings.SplitN(args, "|", 2)
+	word := ""
+	if len(parts) == 2 {
+		word = strings.TrimSpace(parts[1])
+	}
+
+	switch {
+	case parts[0] == "add" && word != "":
+		if !u.addKeyword(word) {
+			u.SendMessage(u.T(i18n.KeywordTooMany, MaxKeywords) + "\n")
+			return
+		}
+		u.SendMessage(u.T(i18n.KeywordAdded, word) + "\n")
+
+	case parts[0] == "del" && word != "":
+		u.removeKeyword(word)
+		u.SendMessage(u.T(i18n.KeywordRemoved, word) + "\n")
+
+	case parts[0] == "list":
+		u.statusLock.Lock()
+		list := strings.Join(u.keywords, ", ")
+		u.statusLock.Unlock()
+		u.SendMessage(u.T(i18n.KeywordList, list) + "\n")
+
+	default:
+		u.SendMessage(u.T(i18n.KeywordBadFormat) + "\n")
+	}
+}
+
+// 添加关键字，已经存在时不重复添加，超过数量限制时返回false
+func (u *User) addKeyword(word string) bool {
+	u.statusLock.Lock()
+	defer u.statusLock.Unlock()
+
+	for _, w := range u.keywords {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+	if len(u.keywords) >= MaxKeywords {
+		return false
+	}
+	u.keywords = append(u.keywords, word)
+	return true
+}
+
+func (u *User) removeKeyword(word string) {
+	u.statusLock.Lock()
+	defer u.statusLock.Unlock()
+
+	for i, w := range u.keywords {
+		if strings.EqualFold(w, word) {
+			u.keywords = append(u.keywords[:i], u.keywords[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package session
+
+import (
+	"SERVER_GO/i18n"
+	"sort"
+	"strings"
+)
+
+// Presence 表示用户的在线状态
+type Presence int
+
+const (
+	Available Presence = iota // 在线
+	Away                      // 离开，长时间不活跃时自动设置
+	Busy                      // 忙碌
+)
+
+// 状态在协议中的名字，例如 status|busy
+var presenceNames = []string{
+	"available",
+	"away",
+	"busy",
+}
+
+var presenceKeys = []i18n.Key{
+	i18n.PresenceAvailable,
+	i18n.PresenceAway,
+	i18n.PresenceBusy,
+}
+
+func (p Presence) String() string {
+	if int(p) < len(presenceNames) {
+		return presenceNames[p]
+	}
+	return "unknown"
+}
+
+// ParsePresence 将协议中的状态名转换成Presence
+func ParsePresence(s string) (Presence, bool) {
+	for i, name := range presenceNames {
+		if strings.EqualFold(s, name) {
+			return Presence(i), true
+		}
+	}
+	return 0, false
+}
+
+// 用户当前的状态以及自定义的状态消息
+type Status struct {
+	Presence Presence
+	Message  string
+	auto     bool // 是否是由于不活跃自动设置的离开
+}
+
+// 按照指定语言显示状态，例如 "忙碌(开会中)"
+func (st Status) Render(l i18n.Locale) string {
+	label := i18n.T(l, presenceKeys[st.Presence])
+	if st.Message == "" {
+		return label
+	}
+	return i18n.T(l, i18n.StatusWithMessage, label, st.Message)
+}
+
+// 获取用户当前的状态
+func (u *User) Status() Status {
+	u.statusLock.Lock()
+	defer u.statusLock.Unlock()
+
+	return u.status
+}
+
+// 设置用户的状态，并通知关注该用户的其他用户
+func (u *User) SetStatus(st Status) {
+	u.statusLock.Lock()
+	changed := u.status != st
+	u.status = st
+	u.statusLock.Unlock()
+
+	if changed {
+		u.notifyPresence(st)
+	}
+}
+
+// 用户长时间不活跃，自动设置为离开；已经是离开或者忙碌时不做处理
+func (u *User) MarkAway() {
+	st := u.Status()
+	if st.Presence != Available {
+		return
+	}
+	st.Presence, st.auto = Away, true
+	u.SetStatus(st)
+}
+
+// 用户重新活跃，如果当前的离开是自动设置的，恢复为在线
+func (u *User) MarkActive() {
+	st := u.Status()
+	if !st.auto {
+		return
+	}
+	st.Presence, st.auto = Available, false
+	u.SetStatus(st)
+}
+
+// 关注某个用户的状态变化，name为 * 表示关注全部用户
+func (u *User) Watch(name string) {
+	u.statusLock.Lock()
+	defer u.statusLock.Unlock()
+
+	u.watching[name] = true
+}
+
+// 取消关注某个用户的状态变化
+func (u *User) Unwatch(name string) {
+	u.statusLock.Lock()
+	defer u.statusLock.Unlock()
+
+	delete(u.watching, name)
+}
+
+// 是否关注了某个用户的状态变化
+func (u *User) IsWatching(name string) bool {
+	u.statusLock.Lock()
+	defer u.statusLock.Unlock()
+
+	return u.watching[name] || u.watching["*"]
+}
+
+// 处理 status|busy|开会中 命令，消息部分可以省略
+func (u *User) doStatus(args string) {
+	parts := strings.SplitN(args, "|", 2)
+	p, ok := ParsePresence(parts[0])
+	if !ok {
+		u.SendMessage(u.T(i18n.StatusBadFormat) + "\n")
+		return
+	}
+
+	st := Status{Presence: p}
+	if len(parts) == 2 {
+		st.Message = strings.TrimSpace(parts[1])
+	}
+	u.SetStatus(st)
+	u.SendMessage(u.T(i18n.StatusDone, st.Render(u.Locale())) + "\n")
+}
+
+// 处理 who 命令，格式为 who|过滤状态|排序方式，两部分都可以省略
+// 过滤状态为 all/available/away/busy，排序方式为 name 或 status
+func (u *User) doWho(args string) {
+	parts := strings.Split(args, "|")
+
+	filter, hasFilter := Presence(0), false
+	if len(parts) > 0 && parts[0] != "" && parts[0] != "all" {
+		p, ok := ParsePresence(parts[0])
+		if !ok {
+			u.SendMessage(u.T(i18n.WhoBadFormat) + "\n")
+			return
+		}
+		filter, hasFilter = p, true
+	}
+
+	byStatus := false
+	if len(parts) > 1 {
+		switch parts[1] {
+		case "", "name":
+		case "status":
+			byStatus = true
+		default:
+			u.SendMessage(u.T(i18n.WhoBadFormat) + "\n")
+			return
+		}
+	}
+
+	type entry struct {
+		addr, name string
+		status     Status
+	}
+
+	users := u.hub.Users().Snapshot()
+	entries := make([]entry, 0, len(users))
+	for _, user := range users {
+		st := user.Status()
+		if hasFilter && st.Presence != filter {
+			continue
+		}
+		entries = append(entries, entry{user.Addr, user.Name(), st})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if byStatus && entries[i].status.Presence != entries[j].status.Presence {
+			return entries[i].status.Presence < entries[j].status.Presence
+		}
+		return entries[i].name < entries[j].name
+	})
+
+	for i, e := range entries {
+		u.SendMessage(u.T(i18n.WhoEntry, i+1, e.addr, e.name, e.status.Render(u.Locale())) + "\n")
+	}
+}
+
+// 将用户的状态变化发送给关注了该用户的其他用户
+func (u *User) notifyPresence(st Status) {
+	name := u.Name()
+	for _, cli := range u.hub.Users().Snapshot() {
+		if cli != u && cli.IsWatching(name) {
+			cli.SendMessage(cli.T(i18n.PresenceChanged, "["+u.Addr+"]"+name, st.Render(cli.Locale())) + "\n")
+		}
+	}
+}
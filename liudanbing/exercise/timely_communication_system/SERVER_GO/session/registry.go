@@ -0,0 +1,96 @@
+package session
+
+import (
+	"strings"
+	"sync"
+)
+
+// Registry 是在线用户的列表，key: Name, value: *User
+// 所有对在线用户的查询和修改都通过Registry完成，锁只保护map本身，
+// 调用者拿到用户之后再发送消息，锁内不做任何网络读写和channel发送
+type Registry struct {
+	lock  sync.RWMutex
+	users map[string]*User
+}
+
+func NewRegistry() *Registry {
+	return &Registry{users: make(map[string]*User)}
+}
+
+// 加入一个用户，用户名已经被使用时返回false
+func (r *Registry) Add(u *User) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	name := u.Name()
+	if _, ok := r.users[name]; ok {
+		return false
+	}
+	r.users[name] = u
+	return true
+}
+
+// 根据用户名查找在线用户
+func (r *Registry) Get(name string) (*User, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	u, ok := r.users[name]
+	return u, ok
+}
+
+// 修改用户名，检查重名和修改在同一把锁内完成
+// 新用户名为空或者已经被使用、用户已经不在列表中(例如已经下线)时返回false
+func (r *Registry) Rename(u *User, newName string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if strings.TrimSpace(newName) == "" {
+		return false
+	}
+	if _, ok := r.users[newName]; ok {
+		return false
+	}
+
+	oldName := u.Name()
+	if r.users[oldName] != u {
+		return false
+	}
+	delete(r.users, oldName)
+	u.name.Store(newName)
+	r.users[newName] = u
+	return true
+}
+
+// 删除一个用户，只有当前登记的确实是这个用户时才删除，返回是否删除
+func (r *Registry) Remove(u *User) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	name := u.Name()
+	if r.users[name] != u {
+		return false
+	}
+	delete(r.users, name)
+	return true
+}
+
+// 返回当前全部在线用户的拷贝，调用者可以在锁外遍历
+func (r *Registry) Snapshot() []*User {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	users := make([]*User, 0, len(r.users))
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+	return users
+}
+
+// 在线用户的数量
+func (r *Registry) Len() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return len(r.users)
+}
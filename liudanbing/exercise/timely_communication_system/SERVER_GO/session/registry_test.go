@@ -0,0 +1,64 @@
+package session
+
+import "testing"
+
+// 只有名字的用户，足够测试Registry
+func namedUser(name string) *User {
+	u := &User{}
+	u.name.Store(name)
+	return u
+}
+
+func TestRegistryRename(t *testing.T) {
+	r := NewRegistry()
+	alice, bob := namedUser("alice"), namedUser("bob")
+	r.Add(alice)
+	r.Add(bob)
+
+	for _, tc := range []struct {
+		name    string
+		newName string
+		ok      bool
+	}{
+		{"taken", "bob", false},
+		{"empty", "", false},
+		{"blank", "  ", false},
+		{"free", "carol", true},
+		{"back to the old name", "alice", true},
+	} {
+		if ok := r.Rename(alice, tc.newName); ok != tc.ok {
+			t.Errorf("%s: Rename(%q) = %v, want %v", tc.name, tc.newName, ok, tc.ok)
+		}
+	}
+	if u, ok := r.Get("alice"); !ok || u != alice || r.Len() != 2 {
+		t.Errorf("after renames: Get(alice) = %v, %v, %d users", u, ok, r.Len())
+	}
+	if _, ok := r.Get("carol"); ok {
+		t.Error("old name carol is still registered")
+	}
+}
+
+// 下线之后处理完的rename不能把用户重新加回列表
+func TestRegistryRenameAfterRemove(t *testing.T) {
+	r := NewRegistry()
+	alice := namedUser("alice")
+	r.Add(alice)
+	r.Remove(alice)
+
+	if r.Rename(alice, "ghost") {
+		t.Error("renamed a user that is no longer registered")
+	}
+	if _, ok := r.Get("ghost"); ok || r.Len() != 0 {
+		t.Errorf("registry has %d users after renaming a removed user", r.Len())
+	}
+
+	// 同名的新用户不受影响
+	other := namedUser("alice")
+	r.Add(other)
+	if r.Rename(alice, "ghost") {
+		t.Error("renamed a removed user whose name is used by someone else")
+	}
+	if u, _ := r.Get("alice"); u != other {
+		t.Error("the other alice was replaced")
+	}
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"SERVER_GO/chatlog"
+	"SERVER_GO/i18n"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// search| 命令最多返回多少条消息
+const SearchLimit = 20
+
+// 消息格式：search|关键字|from=张三|with=李四|room=public|since=2024-01-02|until=2024-01-03
+// 关键字可以为空，例如 search||from=张三；只能查到公聊和自己参与的私聊
+// 用户名可以被别人改成同样的名字，所以只有认证过身份的用户才能查到私聊
+func (u *User) doSearch(args string) {
+	parts := strings.Split(args, "|")
+	q := chatlog.Query{Keyword: parts[0], Limit: SearchLimit}
+	if name, ok := u.Identity(); ok {
+		q.Viewer = name
+	} else {
+		q.Public = true
+	}
+	for _, cond := range parts[1:] {
+		key, value, ok := strings.Cut(cond, "=")
+		if !ok || q.Set(key, value) != nil {
+			u.SendMessage(u.T(i18n.SearchBadFormat) + "\n")
+			return
+		}
+	}
+	if q.Keyword == "" && len(parts) == 1 {
+		u.SendMessage(u.T(i18n.SearchBadFormat) + "\n")
+		return
+	}
+
+	entries, err := u.hub.SearchLog(q)
+	if errors.Is(err, chatlog.ErrDisabled) {
+		u.SendMessage(u.T(i18n.SearchDisabled) + "\n")
+		return
+	}
+	if err != nil {
+		fmt.Println("SearchLog err:", err)
+		u.SendMessage(u.T(i18n.SearchNone) + "\n")
+		return
+	}
+	if len(entries) == 0 {
+		u.SendMessage(u.T(i18n.SearchNone) + "\n")
+		return
+	}
+
+	u.SendMessage(u.T(i18n.SearchFound, len(entries)) + "\n")
+	for _, e := range entries {
+		at := e.Time.Local().Format(time.DateTime)
+		if e.Private() {
+			u.SendMessage(u.T(i18n.SearchPrivate, e.ID, at, e.From, e.To, e.Text) + "\n")
+		} else {
+			u.SendMessage(u.T(i18n.SearchPublic, e.ID, at, e.From, e.Text) + "\n")
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package session
+
+import (
+	"SERVER_GO/filter"
+	"SERVER_GO/i18n"
+	"errors"
+	"SERVER_GO/transport"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 用户channel的缓冲区大小，避免一个慢的客户端拖慢整个广播
+const userChanSize = 32
+
+type User struct {
+	name atomic.Value   // 用户名(string)，只能通过Registry.Rename修改
+	Addr string 
+	C    chan string    // 和用户绑定的channel
+	conn transport.Conn // 是用户唯一可以和对端客户端通信的接口
+	lang atomic.Value   // 当前连接使用的语言(i18n.Locale)，广播goroutine也会读取
+
+	done    chan struct{} // 用户下线时关闭，通知和该用户相关的goroutine退出
+	offline sync.Once     // 保证下线的流程只执行一次
+
+	statusLock sync.Mutex      // 保护status、watching和keywords
+	status     Status          // 在线状态：在线、离开、忙碌以及自定义消息
+	watching   map[string]bool // 关注了哪些用户的状态变化，key: Name
+	keywords   []string        // 公聊消息中出现这些关键字时提醒用户
+
+	lastTyping time.Time // 上一次发送"正在输入"通知的时间，用于限流
+
+	peer          *transport.Credentials // Unix socket 对端进程的身份，其它连接为nil
+	authenticated bool                   // 是否已经按照对端身份自动认证
+	identity      string                 // 认证时使用的用户名
+
+	bot   Bot             // 机器人用户的实现，普通用户为nil
+	inbox chan BotMessage // 机器人收到的消息
+
+	hub Hub // 当前用户所在的server，只通过Hub接口访问
+}
+
+  // 创建一个用户的API
+func NewUser(conn transport.Conn, hub Hub) *User {
+	userAddr := conn.RemoteAddr().String()  // 获取远程客户端的地址
+	user     := &User {
+		Addr: userAddr,
+		C   : make(chan string, userChanSize),
+		conn: conn,
+		done: make(chan struct{}),
+
+		watching: make(map[string]bool),
+
+		hub: hub,
+	}
+	user.name.Store(userAddr)
+	user.lang.Store(i18n.Default)
+	if p, ok := conn.(transport.PeerIdentifier); ok {
+		if cred, ok := p.PeerCredentials(); ok {
+			user.peer = &cred
+		}
+	}
+
+	  // 启动监听当前user channel消息的goroutine
+	go user.ListenMessage()
+
+	return user
+}
+
+  // 每个user都应该启动一个goroutine来处理server的消息，即监控channel，如果有消息就发送给客户端
+func (u *User) ListenMessage() {
+	for {
+		select {
+		case msg := <- u.C:
+			if u.conn == nil { // 机器人没有连接，只通过inbox接收发给它的消息
+				continue
+			}
+			u.conn.Write([]byte(msg + "\n"))  // 这行是意思是将msg + 转义字符\n 转换成byte类型，然后写入到u.conn中，即发送给客户端
+
+		case <- u.done: // 用户下线，退出goroutine
+			return
+		}
+	}
+}
+
+// 用户下线时关闭的channel
+func (u *User) Done() <-chan struct{} {
+	return u.done
+}
+
+// 当前的用户名
+func (u *User) Name() string {
+	return u.name.Load().(string)
+}
+
+// 经过认证的身份，只有按照对端uid自动认证并且仍然使用认证时的用户名时返回true
+// 用户名本身没有密码保护，任何人都可以 rename| 成别人的名字，所以权限和私聊记录只能按照这个身份判断
+func (u *User) Identity() (string, bool) {
+	if !u.authenticated || u.Name() != u.identity {
+		return "", false
+	}
+	return u.identity, true
+}
+
+// 用户上线的业务
+func (u *User) Online() {
+	// 本机的Unix socket连接按照对端uid自动认证，直接使用对应的用户名
+	peerName, hasPeerName := "", false
+	if u.peer != nil {
+		peerName, hasPeerName = u.hub.PeerName(*u.peer)
+	}
+	if hasPeerName {
+		u.name.Store(peerName)
+		u.authenticated = u.hub.Users().Add(u)
+		u.identity = peerName
+	}
+	if u.authenticated {
+		// 记住认证过的身份，其它用户可以给这个身份留言
+		u.hub.Mailbox().Remember(u.identity)
+	}
+
+	// 用户上线了，将用户加入到在线用户列表中
+	if !u.authenticated {
+		// 对应的用户名已经被使用时，仍然使用默认的用户名
+		u.name.Store(u.Addr)
+		u.hub.Users().Add(u)
+	}
+
+	// 广播当前用户上线消息
+	u.hub.BroadCastKey(u, i18n.UserOnline)
+
+	// 给新用户发送欢迎信息
+	u.welcome()
+}
+
+// 用户下线的业务，由Handler在用户断开或者被强踢时调用，多次调用只执行一次
+func (u *User) Offline() {
+	u.offline.Do(func() {
+		// 用户下线，将用户从在线用户列表中删除
+		u.hub.Users().Remove(u)
+
+		// 通知ListenMessage等goroutine退出，再关闭连接
+		close(u.done)
+		if u.conn != nil {
+			u.conn.Close()
+		}
+
+		// 广播当前用户下线
+		u.hub.BroadCastKey(u, i18n.UserOffline)
+	})
+}
+
+// 用户处理消息的业务
+func (u *User) DoMessage(msg string) {
+	if msg == "who" || strings.HasPrefix(msg, "who|") {
+		// 查询当前在线用户有哪些，消息格式：who 或者 who|away|status
+		u.doWho(strings.TrimPrefix(msg[3:], "|"))
+	} else if len(msg) > 7 && msg[:7] == "status|" {
+		// 消息格式：status|busy|开会中
+		u.doStatus(msg[7:])
+	} else if len(msg) > 6 && msg[:6] == "watch|" {
+		// 消息格式：watch|张三，watch|* 关注全部用户
+		u.Watch(msg[6:])
+		u.SendMessage(u.T(i18n.WatchDone, msg[6:]) + "\n")
+	} else if len(msg) > 8 && msg[:8] == "unwatch|" {
+		u.Unwatch(msg[8:])
+		u.SendMessage(u.T(i18n.UnwatchDone, msg[8:]) + "\n")
+	} else if len(msg) > 7 && msg[:7] == "rename|" { // msg[:7]是取msg的前7个字符
+		// 消息格式：rename|张三
+		newName := strings.Split(msg, "|")[1]  // 通过|分割msg，取第二个元素；或者使用msg[7:]来取msg的第8个字符到最后一个字符
+		if strings.TrimSpace(newName) == "" {
+			u.SendMessage(u.T(i18n.RenameEmpty) + "\n")
+		} else if u.hub.IsReservedName(newName) {
+			u.SendMessage(u.T(i18n.RenameReserved) + "\n")
+		} else if !u.hub.Users().Rename(u, newName) { // 判断newName是否存在，不存在时完成修改
+			u.SendMessage(u.T(i18n.RenameTaken) + "\n") // 或者 u.C <- u.T(i18n.RenameTaken) + "\n"
+		} else {
+			u.SendMessage(u.T(i18n.RenameDone, newName) + "\n")
+		}
+	} else if len(msg) > 4 && msg[:3] == "to|" {
+		// 消息格式：to|张三|消息内容
+		
+		// 1. 获取对方用户名
+		remoteName := strings.Split(msg, "|")[1]
+		if remoteName == "" {
+			u.SendMessage(u.T(i18n.PrivateBadFormat) + "\n")
+			return
+		}
+		// 2. 根据用户名得到对方User对象
+		remoteUser, ok := u.hub.Users().Get(remoteName)
+
+		// 3. 获取消息内容，通过对方的User对象将消息内容发送过去
+		parts := strings.SplitN(msg, "|", 3)
+		if len(parts) < 3 {
+			u.SendMessage(u.T(i18n.PrivateBadFormat) + "\n")
+			return
+		}
+		content := parts[2]
+		if content == "" {
+			u.SendMessage(u.T(i18n.PrivateEmpty) + "\n")
+			return
+		}
+
+		content, pass := u.filter(remoteName, content)
+		if !pass {
+			return
+		}
+
+		// 对方不在线时留言，对方以这个名字上线时收到
+		if !ok {
+			if u.leaveMail(remoteName, content) {
+				u.SendMessage(u.T(i18n.PrivateStored, remoteName) + "\n")
+			} else {
+				u.SendMessage(u.T(i18n.PrivateNoUser) + "\n")
+			}
+			return
+		}
+
+		u.Whisper(remoteUser, content)
+	} else if msg == "typing" || strings.HasPrefix(msg, "typing|") {
+		// 消息格式：typing 或者 typing|张三(私聊时只通知对方)
+		u.doTyping(strings.TrimPrefix(msg[6:], "|"))
+	} else if len(msg) > 8 && msg[:8] == "keyword|" {
+		// 消息格式：keyword|add|关键字，keyword|del|关键字，keyword|list
+		u.doKeyword(msg[8:])
+	} else if len(msg) > 5 && msg[:5] == "edit|" {
+		// 消息格式：edit|消息ID|新的内容
+		u.doEdit(msg[5:])
+	} else if len(msg) > 7 && msg[:7] == "delete|" {
+		// 消息格式：delete|消息ID
+		u.doDelete(msg[7:])
+	} else if len(msg) > 7 && msg[:7] == "search|" {
+		// 消息格式：search|关键字|from=张三|since=2024-01-02
+		u.doSearch(msg[7:])
+	} else if msg == "motd" || strings.HasPrefix(msg, "motd|") {
+		// 消息格式：motd 查看每日消息，motd|新的每日消息 (管理员)
+		u.doMOTD(msg)
+	} else if len(msg) > 5 && msg[:5] == "lang|" {
+		// 消息格式：lang|en-US
+		u.SwitchLocale(msg[5:])
+	} else if msg, ok := u.filter("", msg); ok {
+		// 将用户发送的消息进行广播
+		u.hub.BroadCast(u, msg)
+
+	}
+
+}
+
+// 给当前用户的客户端发送消息
+func (u *User) SendMessage(msg string) {
+	if u.conn == nil { // 机器人没有客户端
+		return
+	}
+	u.conn.Write([]byte(msg))
+}
+
+// 内容过滤，to为私聊对象，公聊时为空
+// 消息被拒绝时把原因发回给发送者并返回false
+func (u *User) filter(to, text string) (string, bool) {
+	text, err := u.hub.Filter(filter.Message{From: u.Name(), To: to, Text: text, Sender: u})
+	if err != nil {
+		var r *filter.Rejection
+		if errors.As(err, &r) {
+			u.SendMessage(u.T(r.Key, r.Args...) + "\n")
+		}
+		return "", false
+	}
+	return text, true
+}
+
+// 给另一个用户发送私聊消息，发给机器人的消息会投递到机器人的收件箱
+func (u *User) Whisper(remoteUser *User, content string) {
+	id := u.hub.History().Add(u, remoteUser, content)
+	remoteUser.SendMessage(MsgPrefix(id) + remoteUser.T(i18n.PrivateFrom, u.Name(), content) + "\n")
+	remoteUser.deliver(BotMessage{From: u, Text: content, Private: true})
+	u.SendMessage(MsgPrefix(id) + u.T(i18n.PrivateSent, remoteUser.Name(), content) + "\n")
+}
+
+// 当前连接使用的语言
+func (u *User) Locale() i18n.Locale {
+	return u.lang.Load().(i18n.Locale)
+}
+
+// 按照当前连接的语言翻译消息
+func (u *User) T(key i18n.Key, args ...any) string {
+	return i18n.T(u.Locale(), key, args...)
+}
+
+// 切换当前连接的语言，由客户端连接后协商或者用户通过lang|命令设置
+func (u *User) SwitchLocale(tag string) {
+	l, ok := i18n.Parse(tag)
+	if !ok {
+		names := make([]string, 0, len(i18n.Supported()))
+		for _, s := range i18n.Supported() {
+			names = append(names, string(s))
+		}
+		u.SendMessage(u.T(i18n.LangUnsupported, tag, strings.Join(names, ", ")) + "\n")
+		return
+	}
+
+	u.lang.Store(l)
+	u.SendMessage(u.T(i18n.LangSwitched, l) + "\n")
+}
+// 用户消息前面带上 #ID，客户端通过ID修改或删除消息
+func MsgPrefix(id uint64) string {
+	if id == 0 {
+		return ""
+	}
+	return "#" + strconv.FormatUint(id, 10) + " "
+}
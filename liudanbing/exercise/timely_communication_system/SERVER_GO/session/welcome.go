@@ -0,0 +1,77 @@
+package session
+
+import (
+	"SERVER_GO/i18n"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 上线后的欢迎信息：每日消息、当前在线的用户、未读的离线消息和帮助提示
+// 客户端连接后先发送的 lang| 已经在上线之前处理，所以使用客户端的语言，没有发送时使用默认语言
+func (u *User) welcome() {
+	if u.peer != nil {
+		if u.authenticated {
+			u.SendMessage(u.T(i18n.AuthDone, u.Name(), u.peer.UID) + "\n")
+		} else if name, ok := u.hub.PeerName(*u.peer); ok {
+			u.SendMessage(u.T(i18n.AuthNameTaken, name) + "\n")
+		}
+	}
+
+	if motd := u.hub.MOTD(); motd != "" {
+		u.SendMessage(u.T(i18n.WelcomeMOTD, motd) + "\n")
+	}
+
+	users := u.hub.Users().Snapshot()
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name())
+	}
+	sort.Strings(names)
+	u.SendMessage(u.T(i18n.WelcomeOnline, len(names), strings.Join(names, ", ")) + "\n")
+
+	u.deliverMail()
+
+	u.SendMessage(u.T(i18n.WelcomeHelp) + "\n")
+}
+
+// 发送认证过的身份的离线消息，用户名可以被任何人使用，所以没有认证时不发送
+func (u *User) deliverMail() {
+	name, ok := u.Identity()
+	if !ok {
+		return
+	}
+	mails := u.hub.Mailbox().Take(name)
+	if len(mails) == 0 {
+		return
+	}
+
+	u.SendMessage(u.T(i18n.MailHeader, len(mails)) + "\n")
+	for _, mail := range mails {
+		u.SendMessage(u.T(i18n.MailEntry, mail.Time.Format(time.DateTime), mail.From, mail.Text) + "\n")
+	}
+}
+
+// 给不在线的用户留言，返回是否保存成功
+func (u *User) leaveMail(to, content string) bool {
+	return u.hub.Mailbox().Put(to, Mail{From: u.Name(), Text: content, Time: time.Now()})
+}
+
+// 消息格式：motd 查看每日消息，motd|新的每日消息 由管理员修改，motd| 清空
+func (u *User) doMOTD(msg string) {
+	if msg == "motd" {
+		motd := u.hub.MOTD()
+		if motd == "" {
+			motd = u.T(i18n.MOTDEmpty)
+		}
+		u.SendMessage(u.T(i18n.WelcomeMOTD, motd) + "\n")
+		return
+	}
+
+	if name, ok := u.Identity(); !ok || !u.hub.IsAdmin(name) {
+		u.SendMessage(u.T(i18n.MOTDNotAdmin) + "\n")
+		return
+	}
+	u.hub.SetMOTD(strings.TrimPrefix(msg, "motd|"))
+	u.SendMessage(u.T(i18n.MOTDChanged) + "\n")
+}
@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net"
+
+	"github.com/xtaci/kcp-go/v5"
+)
+
+// KCP 是基于UDP的可靠传输，丢包较多的网络上延迟比TCP低
+// 注意KCP没有建立和断开连接的过程：客户端连接后要先发送数据，服务器才能Accept；
+// 客户端直接退出时服务器要等到超时强踢才会让用户下线
+
+// ListenKCP 在addr上监听KCP连接，例如 127.0.0.1:8888，端口为0时使用随机端口
+func ListenKCP(addr string) (Listener, error) {
+	l, err := kcp.ListenWithOptions(addr, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return kcpListener{l}, nil
+}
+
+// DialKCP 连接KCP服务器，客户端和测试使用
+func DialKCP(addr string) (net.Conn, error) {
+	sess, err := kcp.DialWithOptions(addr, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newKCPConn(sess), nil
+}
+
+type kcpListener struct {
+	*kcp.Listener
+}
+
+func (l kcpListener) Accept() (Conn, error) {
+	sess, err := l.AcceptKCP()
+	if err != nil {
+		return nil, closedErr(err)
+	}
+	return newKCPConn(sess), nil
+}
+
+// 聊天消息都很短，使用快速模式：关闭延迟确认，10ms刷新一次，2次跳过就快速重传，关闭拥塞控制
+func newKCPConn(sess *kcp.UDPSession) kcpConn {
+	sess.SetStreamMode(true)
+	sess.SetNoDelay(1, 10, 2, 1)
+	sess.SetACKNoDelay(true)
+	sess.SetWindowSize(128, 128)
+	return kcpConn{sess}
+}
+
+// kcpConn 把KCP关闭之后的错误转换成 net.ErrClosed，和TCP的行为保持一致
+type kcpConn struct {
+	*kcp.UDPSession
+}
+
+func (c kcpConn) Read(b []byte) (int, error) {
+	n, err := c.UDPSession.Read(b)
+	return n, closedErr(err)
+}
+
+func (c kcpConn) Write(b []byte) (int, error) {
+	n, err := c.UDPSession.Write(b)
+	return n, closedErr(err)
+}
+
+func closedErr(err error) error {
+	if errors.Is(err, io.ErrClosedPipe) {
+		return net.ErrClosed
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package transport_test
+
+import (
+	"SERVER_GO/chattest"
+	"SERVER_GO/transport"
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// 通过丢包30%的代理发送1000行，对方按顺序全部收到
+func TestKCPOverLossyLink(t *testing.T) {
+	l, err := transport.ListenKCP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	received := make(chan string, 1000)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			received <- scanner.Text()
+		}
+	}()
+
+	conn, err := transport.DialKCP(chattest.LossyUDPProxy(t, l.Addr().String(), 0.3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for i := 0; i < 1000; i++ {
+		if _, err := fmt.Fprintf(conn, "line %d\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 1000; i++ {
+		select {
+		case line := <-received:
+			if want := fmt.Sprintf("line %d", i); line != want {
+				t.Fatalf("got %q, want %q", line, want)
+			}
+		case <-timeout:
+			t.Fatalf("timed out after %d lines", i)
+		}
+	}
+}
+
+// 关闭之后和TCP一样返回 net.ErrClosed，服务器据此区分正常退出和错误
+func TestKCPClosedErrors(t *testing.T) {
+	l, err := transport.ListenKCP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := transport.DialKCP(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Read after Close: %v", err)
+	}
+
+	l.Close()
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept after Close: %v", err)
+	}
+}
+
+func TestListenUnknownTransport(t *testing.T) {
+	if _, err := transport.Listen("sctp", "127.0.0.1:0"); err == nil {
+		t.Fatal("unknown transport accepted")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package transport
+
+import (
+	"net"
+	"syscall"
+)
+
+// 通过 SO_PEERCRED 取得对端进程的uid、gid和pid
+func peerCredentials(c *net.UnixConn) (Credentials, bool) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return Credentials{}, false
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil || credErr != nil {
+		return Credentials{}, false
+	}
+	return Credentials{UID: int(cred.Uid), GID: int(cred.Gid), PID: int(cred.Pid)}, true
+}
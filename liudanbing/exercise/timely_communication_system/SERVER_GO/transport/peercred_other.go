@@ -0,0 +1,10 @@
+//go:build !linux
+
+package transport
+
+import "net"
+
+// 其它平台暂时不支持取得对端进程的身份，Unix socket 连接不会自动认证
+func peerCredentials(c *net.UnixConn) (Credentials, bool) {
+	return Credentials{}, false
+}
@@ -0,0 +1,65 @@
+// transport 是聊天服务器的传输层，服务器核心只依赖这里的接口，
+// 不关心连接底层是TCP还是其它协议
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
+// 支持的传输协议
+const (
+	TCP  = "tcp"
+	KCP  = "kcp"
+	Unix = "unix"
+)
+
+// Conn 是和一个客户端之间的连接，net.Conn 满足这个接口
+// 连接被关闭之后 Read 和 Write 返回 net.ErrClosed
+type Conn interface {
+	io.ReadWriteCloser
+	RemoteAddr() net.Addr
+}
+
+// Listener 接受客户端的连接，Close 之后 Accept 返回 net.ErrClosed
+type Listener interface {
+	Accept() (Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
+// Listen 按照network(tcp、kcp、unix)在addr上监听，unix的addr为socket文件的路径
+func Listen(network, addr string) (Listener, error) {
+	switch network {
+	case TCP, "":
+		return ListenTCP(addr)
+	case KCP:
+		return ListenKCP(addr)
+	case Unix:
+		return ListenUnix(addr)
+	}
+	return nil, fmt.Errorf("unknown transport %q", network)
+}
+
+// ListenTCP 在addr上监听TCP连接，例如 127.0.0.1:8888，端口为0时使用随机端口
+func ListenTCP(addr string) (Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(l), nil
+}
+
+// Wrap 把一个 net.Listener 转换成 Listener
+func Wrap(l net.Listener) Listener {
+	return netListener{l}
+}
+
+type netListener struct {
+	net.Listener
+}
+
+func (l netListener) Accept() (Conn, error) {
+	return l.Listener.Accept()
+}
@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// Credentials 是对端进程的身份，由操作系统提供(Linux 的 SO_PEERCRED)，客户端无法伪造
+type Credentials struct {
+	UID int
+	GID int
+	PID int
+}
+
+// PeerIdentifier 由可以取得对端进程身份的连接实现，目前只有Linux上的Unix socket连接
+type PeerIdentifier interface {
+	PeerCredentials() (Credentials, bool)
+}
+
+// ListenUnix 在path上监听Unix socket，同一台机器上的程序不需要TCP端口就可以连接
+// path已经存在但是没有服务器在监听时(上次没有正常退出)先删除旧的socket文件
+func ListenUnix(path string) (Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil && isStaleSocket(path) {
+		os.Remove(path)
+		l, err = net.Listen("unix", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &unixListener{Listener: l}, nil
+}
+
+// 是否是没有服务器在监听的socket文件
+func isStaleSocket(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return false
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return false
+	}
+	return true
+}
+
+type unixListener struct {
+	net.Listener
+	next atomic.Uint64 // 给每个连接编号
+}
+
+func (l *unixListener) Accept() (Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return conn, nil
+	}
+
+	c := &unixConn{UnixConn: uc, addr: unixPeerAddr("unix:" + strconv.FormatUint(l.next.Add(1), 10))}
+	c.cred, c.credOK = peerCredentials(uc)
+	return c, nil
+}
+
+// unixConn 的对端地址是空的，服务器用地址作为默认用户名，所以给每个连接一个不重复的地址，例如 unix:1
+type unixConn struct {
+	*net.UnixConn
+	addr   unixPeerAddr
+	cred   Credentials
+	credOK bool
+}
+
+func (c *unixConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func (c *unixConn) PeerCredentials() (Credentials, bool) {
+	return c.cred, c.credOK
+}
+
+type unixPeerAddr string
+
+func (a unixPeerAddr) Network() string { return "unix" }
+func (a unixPeerAddr) String() string  { return string(a) }
+
+// 编译时检查
+var _ PeerIdentifier = (*unixConn)(nil)
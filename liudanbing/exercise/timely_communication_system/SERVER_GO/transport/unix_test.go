@@ -0,0 +1,70 @@
+package transport_test
+
+import (
+	"SERVER_GO/transport"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUnixPeerCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.sock")
+	l, err := transport.Listen(transport.Unix, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 1; i <= 2; i++ {
+		client, err := net.Dial("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		conn, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		// 每个连接的地址不同，可以作为默认用户名
+		if want := "unix:" + string(rune('0'+i)); conn.RemoteAddr().String() != want {
+			t.Errorf("RemoteAddr = %q, want %q", conn.RemoteAddr(), want)
+		}
+
+		cred, ok := conn.(transport.PeerIdentifier).PeerCredentials()
+		if runtime.GOOS != "linux" {
+			continue
+		}
+		if !ok || cred.UID != os.Getuid() || cred.PID != os.Getpid() {
+			t.Errorf("PeerCredentials = %+v, %v, want uid %d pid %d", cred, ok, os.Getuid(), os.Getpid())
+		}
+	}
+}
+
+// 上次没有正常退出留下的socket文件不影响再次监听，正在使用的socket不能被抢占
+func TestUnixStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.sock")
+	old, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	if _, err := transport.ListenUnix(path); err == nil {
+		t.Fatal("listened on a socket that is in use")
+	}
+
+	old.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal("stale socket file is missing:", err)
+	}
+	l, err := transport.ListenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+}
@@ -1,3 +0,0 @@
-module SERVER_GO_ERROR
-
-go 1.23.4